@@ -1,11 +1,17 @@
 package main
 
 import (
+	"ai-agent-hub/internal/analytics"
 	"ai-agent-hub/internal/database"
+	"ai-agent-hub/internal/mail"
 	"ai-agent-hub/internal/routes"
+	"ai-agent-hub/internal/similar"
 	"ai-agent-hub/internal/utils"
+	"context"
 	"log"
+	"net"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
@@ -22,21 +28,62 @@ func loadEnv() {
     }
 }
 
+// ipExtractor decides where c.RealIP() comes from. By default it is the
+// connection's address and forwarding headers are ignored, since anyone can
+// set them. Behind a reverse proxy, list its addresses in TRUSTED_PROXIES
+// (comma-separated CIDRs) to take the client IP from X-Forwarded-For.
+func ipExtractor() echo.IPExtractor {
+    proxies := os.Getenv("TRUSTED_PROXIES")
+    if proxies == "" {
+        return echo.ExtractIPDirect()
+    }
+
+    options := []echo.TrustOption{
+        echo.TrustLoopback(false),
+        echo.TrustLinkLocal(false),
+        echo.TrustPrivateNet(false),
+    }
+    for _, cidr := range strings.Split(proxies, ",") {
+        _, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+        if err != nil {
+            log.Fatalf("Invalid TRUSTED_PROXIES entry %q: %v", cidr, err)
+        }
+        options = append(options, echo.TrustIPRange(ipNet))
+    }
+    return echo.ExtractIPFromXFFHeader(options...)
+}
+
 func main() {
 
     loadEnv()
     
     db := database.Connect()
+    database.EnsureAdmins(db, os.Getenv("ADMIN_EMAILS"))
     e := echo.New()
     e.Validator = utils.NewValidator()
+    e.IPExtractor = ipExtractor()
 
     // Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
+    // Deliver queued emails (verification, password reset) in the background
+    mailer, err := mail.NewMailerFromEnv()
+    if err != nil {
+        log.Fatal(err)
+    }
+    go mail.NewDispatcher(db, mailer).Run(context.Background())
+    // Fold view/chat events into daily per-agent stats
+    go analytics.NewRollup(db).Run(context.Background())
+    // Recompute the time-decayed trending ranking
+    go analytics.NewTrending(db, analytics.TrendingConfigFromEnv()).Run(context.Background())
+    // Keep the "similar agents" cache fresh as agents change
+    go similar.NewRefresher(db).Run(context.Background())
+
     routes.RegisterPublicRoutes(e, db)
     routes.RegisterPrivateRoutes(e, db)
+    routes.RegisterAdminRoutes(e, db)
 
     port := os.Getenv("PORT")
 	if port == "" {
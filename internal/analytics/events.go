@@ -0,0 +1,44 @@
+package analytics
+
+import (
+	"ai-agent-hub/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ViewWindow is how long repeat views from the same viewer are ignored.
+// Views are counted once per viewer per window-long slot of the clock, so a
+// repeat just after a slot boundary counts again.
+const ViewWindow = 30 * time.Minute
+
+// RecordView stores a view unless the viewer already viewed the agent in the
+// current ViewWindow slot, and bumps the agent's ViewCount when it counts. It
+// reports whether the view was counted. De-duplication rests on the unique
+// (agent, viewer, slot) index, so concurrent views need no lock.
+func RecordView(db *gorm.DB, agentID uint, viewerKey string) (bool, error) {
+	counted := false
+	bucket := time.Now().Unix() / int64(ViewWindow/time.Second)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "agent_id"}, {Name: "viewer_key"}, {Name: "view_bucket"}},
+			DoNothing: true,
+		}).Create(&models.AgentEvent{AgentID: agentID, Kind: models.EventView, ViewerKey: viewerKey, ViewBucket: &bucket})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		counted = true
+		return tx.Model(&models.Agent{}).Where("id = ?", agentID).
+			UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error
+	})
+
+	return counted, err
+}
+
+// RecordChat stores one chat turn. Every turn counts.
+func RecordChat(db *gorm.DB, agentID uint, viewerKey string) error {
+	return db.Create(&models.AgentEvent{AgentID: agentID, Kind: models.EventChat, ViewerKey: viewerKey}).Error
+}
@@ -0,0 +1,27 @@
+package analytics
+
+import "gorm.io/gorm"
+
+// Background jobs that rewrite a whole table take an advisory lock so that
+// only one instance runs them at a time. The keys use the two-int form of
+// the pg_advisory functions, with jobLockClass as the first int.
+const (
+	jobLockClass = 1
+
+	JobTrending = 1
+	JobSimilar  = 2
+)
+
+// TryJobLock takes job's lock for the rest of tx. It reports false, without
+// waiting, when another instance holds it.
+func TryJobLock(tx *gorm.DB, job int) (bool, error) {
+	var locked bool
+	err := tx.Raw("SELECT pg_try_advisory_xact_lock(?, ?)", jobLockClass, job).Scan(&locked).Error
+	return locked, err
+}
+
+// JobLock takes job's lock for the rest of tx, waiting for another instance
+// to release it.
+func JobLock(tx *gorm.DB, job int) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", jobLockClass, job).Error
+}
@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"ai-agent-hub/internal/models"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	rollupInterval = 5 * time.Minute
+	// Retention is how long raw events are kept after being rolled up.
+	Retention = 90 * 24 * time.Hour
+)
+
+// Rollup periodically folds agent events into daily stats and prunes old
+// events.
+type Rollup struct {
+	DB      *gorm.DB
+	lastRun time.Time
+}
+
+func NewRollup(db *gorm.DB) *Rollup {
+	return &Rollup{DB: db}
+}
+
+// Run rolls up events until ctx is cancelled.
+func (r *Rollup) Run(ctx context.Context) {
+	ticker := time.NewTicker(rollupInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.RollupOnce(ctx); err != nil {
+			log.Printf("analytics rollup: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RollupOnce recomputes the daily stats for every day since the last run
+// (all retained days on the first run) and prunes events past Retention.
+// Whole days are recomputed, so running it again is harmless.
+func (r *Rollup) RollupOnce(ctx context.Context) error {
+	now := time.Now().UTC()
+	// Pruning stops at a day boundary so a day is never half gone when it
+	// is recomputed.
+	cutoff := startOfDay(now.Add(-Retention))
+	since := cutoff
+	if !r.lastRun.IsZero() {
+		since = startOfDay(r.lastRun)
+	}
+
+	db := r.DB.WithContext(ctx)
+	err := db.Exec(`INSERT INTO agent_daily_stats (agent_id, day, views, unique_viewers, chats)
+		SELECT agent_id, (created_at AT TIME ZONE 'UTC')::date,
+			COUNT(*) FILTER (WHERE kind = ?),
+			COUNT(DISTINCT viewer_key) FILTER (WHERE kind = ?),
+			COUNT(*) FILTER (WHERE kind = ?)
+		FROM agent_events
+		WHERE created_at >= ?
+		GROUP BY 1, 2
+		ON CONFLICT (agent_id, day) DO UPDATE SET
+			views = EXCLUDED.views,
+			unique_viewers = EXCLUDED.unique_viewers,
+			chats = EXCLUDED.chats`,
+		models.EventView, models.EventView, models.EventChat, since).Error
+	if err != nil {
+		return err
+	}
+
+	if err := db.Where("created_at < ?", cutoff).Delete(&models.AgentEvent{}).Error; err != nil {
+		return err
+	}
+
+	r.lastRun = now
+	return nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
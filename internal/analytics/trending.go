@@ -0,0 +1,152 @@
+package analytics
+
+import (
+	"ai-agent-hub/internal/models"
+	"context"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrendingConfig weights an agent's recent views, likes and chats. Each
+// signal counts for its weight, halving every HalfLife; signals older than
+// Window are ignored.
+type TrendingConfig struct {
+	ViewWeight float64
+	LikeWeight float64
+	ChatWeight float64
+	HalfLife   time.Duration
+	Window     time.Duration
+	Interval   time.Duration
+}
+
+var DefaultTrendingConfig = TrendingConfig{
+	ViewWeight: 1,
+	LikeWeight: 5,
+	ChatWeight: 3,
+	HalfLife:   48 * time.Hour,
+	Window:     14 * 24 * time.Hour,
+	Interval:   10 * time.Minute,
+}
+
+// TrendingConfigFromEnv overrides the defaults with TRENDING_VIEW_WEIGHT,
+// TRENDING_LIKE_WEIGHT, TRENDING_CHAT_WEIGHT, TRENDING_HALF_LIFE,
+// TRENDING_WINDOW and TRENDING_INTERVAL (durations like "36h").
+func TrendingConfigFromEnv() TrendingConfig {
+	cfg := DefaultTrendingConfig
+	envFloat("TRENDING_VIEW_WEIGHT", &cfg.ViewWeight)
+	envFloat("TRENDING_LIKE_WEIGHT", &cfg.LikeWeight)
+	envFloat("TRENDING_CHAT_WEIGHT", &cfg.ChatWeight)
+	envDuration("TRENDING_HALF_LIFE", &cfg.HalfLife)
+	envDuration("TRENDING_WINDOW", &cfg.Window)
+	envDuration("TRENDING_INTERVAL", &cfg.Interval)
+
+	// Views and chats older than Retention are gone anyway.
+	if cfg.Window > Retention {
+		cfg.Window = Retention
+	}
+	return cfg
+}
+
+func envFloat(key string, dst *float64) {
+	if s := os.Getenv(key); s != "" {
+		if v, err := strconv.ParseFloat(s, 64); err == nil && v >= 0 {
+			*dst = v
+		} else {
+			log.Printf("ignoring invalid %s=%q", key, s)
+		}
+	}
+}
+
+func envDuration(key string, dst *time.Duration) {
+	if s := os.Getenv(key); s != "" {
+		if v, err := time.ParseDuration(s); err == nil && v > 0 {
+			*dst = v
+		} else {
+			log.Printf("ignoring invalid %s=%q", key, s)
+		}
+	}
+}
+
+// Trending periodically recomputes agent_trending_scores so the trending
+// list is a plain indexed read.
+type Trending struct {
+	DB     *gorm.DB
+	Config TrendingConfig
+}
+
+func NewTrending(db *gorm.DB, cfg TrendingConfig) *Trending {
+	return &Trending{DB: db, Config: cfg}
+}
+
+// Run refreshes the scores until ctx is cancelled.
+func (t *Trending) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.Config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.RefreshOnce(ctx); err != nil {
+			log.Printf("trending: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshOnce replaces every score in one transaction, so readers see
+// either the old ranking or the new one. When another instance is already
+// refreshing, it does nothing.
+func (t *Trending) RefreshOnce(ctx context.Context) error {
+	cfg := t.Config
+	now := time.Now()
+	since := now.Add(-cfg.Window)
+	decay := math.Ln2 / cfg.HalfLife.Seconds()
+
+	return t.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if locked, err := TryJobLock(tx, JobTrending); err != nil || !locked {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM agent_trending_scores").Error; err != nil {
+			return err
+		}
+		// Parameters are cast explicitly: Postgres can't infer their types
+		// inside CASE or a bare select list.
+		return tx.Exec(`WITH signals AS (
+				SELECT agent_id, kind, created_at FROM agent_events WHERE created_at >= CAST(@since AS timestamptz)
+				UNION ALL
+				SELECT agent_id, 'like', created_at FROM agent_likes WHERE created_at >= CAST(@since AS timestamptz)
+			)
+			INSERT INTO agent_trending_scores (agent_id, score, views, likes, chats, computed_at)
+			SELECT s.agent_id,
+				SUM(CASE s.kind
+						WHEN @view THEN CAST(@view_weight AS float8)
+						WHEN @chat THEN CAST(@chat_weight AS float8)
+						ELSE CAST(@like_weight AS float8)
+					END * EXP(-CAST(@decay AS float8) *
+						EXTRACT(EPOCH FROM (CAST(@now AS timestamptz) - s.created_at))::float8)),
+				COUNT(*) FILTER (WHERE s.kind = @view),
+				COUNT(*) FILTER (WHERE s.kind = 'like'),
+				COUNT(*) FILTER (WHERE s.kind = @chat),
+				CAST(@now AS timestamptz)
+			FROM signals s
+			JOIN agents a ON a.id = s.agent_id AND a.deleted_at IS NULL
+			GROUP BY s.agent_id`,
+			map[string]any{
+				"since":       since,
+				"now":         now,
+				"decay":       decay,
+				"view":        models.EventView,
+				"chat":        models.EventChat,
+				"view_weight": cfg.ViewWeight,
+				"chat_weight": cfg.ChatWeight,
+				"like_weight": cfg.LikeWeight,
+			}).Error
+	})
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"ai-agent-hub/internal/models"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Purposes of the single-use tokens sent by email.
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+)
+
+const (
+	VerifyEmailTTL   = 48 * time.Hour
+	ResetPasswordTTL = time.Hour
+)
+
+var ErrInvalidActionToken = errors.New("invalid or expired token")
+
+// actionPayload is the signed part of an action token. The nonce ties the
+// token to its UserToken row, which is what makes it single-use.
+type actionPayload struct {
+	Purpose   string `json:"p"`
+	UserID    uint   `json:"u"`
+	Nonce     string `json:"n"`
+	ExpiresAt int64  `json:"e"`
+}
+
+func actionMAC(payload []byte) []byte {
+	// Domain-separate from JWT signatures that share the same secret.
+	mac := hmac.New(sha256.New, []byte("action-token:"+os.Getenv("JWT_SECRET")))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func signAction(p actionPayload) (string, error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(actionMAC(payload)), nil
+}
+
+func parseAction(token string) (actionPayload, error) {
+	var p actionPayload
+
+	encPayload, encSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return p, ErrInvalidActionToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return p, ErrInvalidActionToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil || !hmac.Equal(sig, actionMAC(payload)) {
+		return p, ErrInvalidActionToken
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return p, ErrInvalidActionToken
+	}
+	if time.Now().Unix() > p.ExpiresAt {
+		return p, ErrInvalidActionToken
+	}
+	return p, nil
+}
+
+// IssueActionToken creates a signed, single-use token for userID. Any
+// earlier unused token for the same purpose stops working.
+func IssueActionToken(db *gorm.DB, userID uint, purpose string, ttl time.Duration) (string, error) {
+	nonce, err := RandomToken(24)
+	if err != nil {
+		return "", err
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			NonceHash: HashToken(nonce),
+			ExpiresAt: expiresAt,
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return signAction(actionPayload{Purpose: purpose, UserID: userID, Nonce: nonce, ExpiresAt: expiresAt.Unix()})
+}
+
+// ConsumeActionToken checks a token and marks it used, returning the user
+// it was issued to. A token can be consumed only once.
+func ConsumeActionToken(db *gorm.DB, token, purpose string) (uint, error) {
+	p, err := parseAction(token)
+	if err != nil {
+		return 0, err
+	}
+	if p.Purpose != purpose {
+		return 0, ErrInvalidActionToken
+	}
+
+	res := db.Model(&models.UserToken{}).
+		Where("nonce_hash = ? AND user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?",
+			HashToken(p.Nonce), p.UserID, purpose, time.Now()).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return 0, ErrInvalidActionToken
+	}
+	return p.UserID, nil
+}
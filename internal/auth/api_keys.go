@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"ai-agent-hub/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyHeader is the request header API keys are sent in.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix makes hub keys easy to recognise, e.g. by secret scanners.
+const apiKeyPrefix = "aah_"
+
+// Scopes an API key can be granted. Each covers one resource under /api/my;
+// "read" allows safe methods and "write" everything else.
+var APIKeyScopes = []string{
+	"agents:read", "agents:write",
+	"conversations:read", "conversations:write",
+	"favorites:read",
+	"profile:read", "profile:write",
+}
+
+var ErrInvalidAPIKey = errors.New("invalid, expired or revoked API key")
+
+// ValidScope reports whether scope is one of APIKeyScopes.
+func ValidScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateAPIKey returns a new raw key together with the display prefix
+// and the hash to store.
+func GenerateAPIKey() (raw, prefix, hash string, err error) {
+	secret, err := RandomToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+	raw = apiKeyPrefix + secret
+	return raw, raw[:len(apiKeyPrefix)+8], HashToken(raw), nil
+}
+
+// AuthenticateAPIKey looks up a raw key and the user it belongs to.
+func AuthenticateAPIKey(db *gorm.DB, raw string) (models.APIKey, models.User, error) {
+	var key models.APIKey
+	var user models.User
+
+	if err := db.Where("key_hash = ?", HashToken(raw)).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return key, user, ErrInvalidAPIKey
+		}
+		return key, user, err
+	}
+	if !key.Active() {
+		return key, user, ErrInvalidAPIKey
+	}
+
+	if err := db.First(&user, key.UserID).Error; err != nil || user.Disabled() {
+		return key, user, ErrInvalidAPIKey
+	}
+
+	// Only touch last_used_at once a minute to avoid a write per request.
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > time.Minute {
+		now := time.Now()
+		db.Model(&key).UpdateColumn("last_used_at", now)
+		key.LastUsedAt = &now
+	}
+
+	return key, user, nil
+}
@@ -0,0 +1,203 @@
+// Package auth issues access and refresh tokens and tracks login sessions.
+package auth
+
+import (
+	"ai-agent-hub/internal/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused means an already rotated token was presented
+	// again. The whole session is revoked when this happens since the
+	// token has most likely been stolen.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+type TokenPair struct {
+	AccessToken  string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// RandomToken returns n random bytes encoded for use in URLs and headers.
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken is how opaque tokens are stored: only their SHA-256.
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignAccessToken creates a short-lived JWT bound to a session.
+func SignAccessToken(user models.User, sessionID string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(AccessTokenTTL)
+
+	claims := jwt.MapClaims{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"role":    user.Role,
+		"sid":     sessionID,
+		"iat":     now.Unix(),
+		"exp":     expiresAt.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	return signed, expiresAt, err
+}
+
+// issue stores a new refresh token in the family and signs a matching
+// access token.
+func issue(tx *gorm.DB, user models.User, familyID string) (TokenPair, error) {
+	raw, err := RandomToken(32)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	rt := models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: HashToken(raw),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}
+	if err := tx.Create(&rt).Error; err != nil {
+		return TokenPair{}, err
+	}
+
+	access, expiresAt, err := SignAccessToken(user, familyID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{AccessToken: access, RefreshToken: raw, ExpiresAt: expiresAt}, nil
+}
+
+// CreateSession starts a new login session for user.
+func CreateSession(db *gorm.DB, user models.User) (TokenPair, error) {
+	familyID, err := RandomToken(24)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return issue(db, user, familyID)
+}
+
+// Rotate exchanges a refresh token for a new token pair. Each refresh
+// token can be used exactly once.
+func Rotate(db *gorm.DB, raw string) (TokenPair, error) {
+	var pair TokenPair
+	var reused bool
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var rt models.RefreshToken
+		if err := tx.Where("token_hash = ?", HashToken(raw)).First(&rt).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if rt.RevokedAt != nil || time.Now().After(rt.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		// Claim the token atomically so two concurrent refreshes cannot
+		// both succeed.
+		now := time.Now()
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", rt.ID).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			reused = true
+			return ErrRefreshTokenReused
+		}
+
+		var user models.User
+		if err := tx.First(&user, rt.UserID).Error; err != nil || user.Disabled() {
+			return ErrInvalidRefreshToken
+		}
+
+		var err error
+		pair, err = issue(tx, user, rt.FamilyID)
+		return err
+	})
+
+	if reused {
+		// Revoke outside the rolled-back transaction so it sticks.
+		var rt models.RefreshToken
+		if db.Where("token_hash = ?", HashToken(raw)).First(&rt).Error == nil {
+			if err := RevokeSession(db, rt.FamilyID); err != nil {
+				return TokenPair{}, err
+			}
+		}
+	}
+	return pair, err
+}
+
+// RevokeSession revokes every refresh token of a session, which also makes
+// its access tokens fail SessionActive.
+func RevokeSession(db *gorm.DB, familyID string) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserSessions logs a user out everywhere.
+func RevokeUserSessions(db *gorm.DB, userID uint) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeOtherSessions logs a user out everywhere except the given session.
+func RevokeOtherSessions(db *gorm.DB, userID uint, keepFamilyID string) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeByRefreshToken revokes the session a refresh token belongs to.
+// Unknown tokens are ignored so logout is idempotent.
+func RevokeByRefreshToken(db *gorm.DB, raw string) error {
+	var rt models.RefreshToken
+	if err := db.Where("token_hash = ?", HashToken(raw)).First(&rt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return RevokeSession(db, rt.FamilyID)
+}
+
+// SessionActive reports whether a session still has unrevoked, unexpired
+// refresh tokens.
+func SessionActive(db *gorm.DB, familyID string) (bool, error) {
+	var count int64
+	err := db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL AND expires_at > ?", familyID, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
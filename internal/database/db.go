@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -39,9 +40,66 @@ func Connect() *gorm.DB {
     return db
 }
 func Migrate(db *gorm.DB) {
-    err := db.AutoMigrate(&models.User{}, &models.Agent{})
+    err := db.AutoMigrate(
+        &models.User{},
+        &models.Category{},
+        &models.Tag{},
+        &models.Agent{},
+        &models.Conversation{},
+        &models.Message{},
+        &models.RefreshToken{},
+        &models.UserToken{},
+        &models.OutboxEmail{},
+        &models.ModerationAction{},
+        &models.APIKey{},
+        &models.AgentVersion{},
+        &models.AgentLike{},
+        &models.Review{},
+        &models.AgentEvent{},
+        &models.AgentDailyStat{},
+        &models.AgentTrendingScore{},
+        &models.AgentSimilarity{},
+    )
     if err != nil {
         log.Fatal("❌ Failed DB migration:", err)
     }
+    if err := migrateSearch(db); err != nil {
+        log.Fatal("❌ Failed search migration:", err)
+    }
     fmt.Println("✅ Database migrated")
-}
\ No newline at end of file
+}
+
+// migrateSearch adds the weighted full-text column behind
+// GET /api/agents/search. Postgres keeps it up to date as a generated column,
+// so no handler has to remember to refresh it.
+func migrateSearch(db *gorm.DB) error {
+    err := db.Exec(`ALTER TABLE agents ADD COLUMN IF NOT EXISTS search_vector tsvector
+        GENERATED ALWAYS AS (
+            setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+            setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+            setweight(to_tsvector('english', coalesce(personality, '')), 'C')
+        ) STORED`).Error
+    if err != nil {
+        return err
+    }
+    return db.Exec("CREATE INDEX IF NOT EXISTS idx_agents_search_vector ON agents USING GIN (search_vector)").Error
+}
+
+// EnsureAdmins promotes the users with the given comma-separated emails to
+// admin, so a fresh deployment has someone who can reach /api/admin.
+func EnsureAdmins(db *gorm.DB, emails string) {
+    var list []string
+    for _, email := range strings.Split(emails, ",") {
+        if email = strings.TrimSpace(email); email != "" {
+            list = append(list, email)
+        }
+    }
+    if len(list) == 0 {
+        return
+    }
+
+    err := db.Model(&models.User{}).Where("email IN ?", list).Update("role", models.RoleAdmin).Error
+    if err != nil {
+        log.Println("❌ Failed to promote admins:", err)
+    }
+}
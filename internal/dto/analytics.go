@@ -0,0 +1,25 @@
+package dto
+
+// DailyStat is one day of an agent's analytics. Date is YYYY-MM-DD (UTC).
+type DailyStat struct {
+	Date          string `json:"date"`
+	Views         int64  `json:"views"`
+	UniqueViewers int64  `json:"uniqueViewers"`
+	Chats         int64  `json:"chats"`
+}
+
+type AnalyticsTotals struct {
+	Views int64 `json:"views"`
+	// UniqueViewers is distinct across the whole range, not a sum of days.
+	UniqueViewers int64 `json:"uniqueViewers"`
+	Chats         int64 `json:"chats"`
+}
+
+type AgentAnalytics struct {
+	AgentID      uint            `json:"agentId"`
+	From         string          `json:"from"`
+	To           string          `json:"to"`
+	AllTimeViews uint            `json:"allTimeViews"`
+	Totals       AnalyticsTotals `json:"totals"`
+	Series       []DailyStat     `json:"series"`
+}
@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"ai-agent-hub/internal/models"
+	"time"
+)
+
+type APIKey struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedAPIKey is returned once, when a key is created. It is the only
+// time the full key is ever sent.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+func NewAPIKey(k models.APIKey) APIKey {
+	scopes := k.ScopeList()
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return APIKey{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
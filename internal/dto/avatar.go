@@ -0,0 +1,8 @@
+package dto
+
+// Avatar is the result of an avatar upload. URL is the default size;
+// Sizes maps each thumbnail edge in pixels to its URL.
+type Avatar struct {
+	URL   string            `json:"avatar"`
+	Sizes map[string]string `json:"sizes"`
+}
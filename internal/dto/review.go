@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"ai-agent-hub/internal/models"
+	"time"
+)
+
+// Reviewer is the public identity shown next to a review.
+type Reviewer struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+}
+
+type Review struct {
+	ID             uint       `json:"id"`
+	AgentID        uint       `json:"agentId"`
+	Rating         int        `json:"rating"`
+	Body           string     `json:"body"`
+	Author         Reviewer   `json:"author"`
+	OwnerReply     string     `json:"ownerReply"`
+	OwnerRepliedAt *time.Time `json:"ownerRepliedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// NewReview maps a review; r.User must be preloaded for the author to be
+// filled in.
+func NewReview(r models.Review) Review {
+	return Review{
+		ID:      r.ID,
+		AgentID: r.AgentID,
+		Rating:  r.Rating,
+		Body:    r.Body,
+		Author: Reviewer{
+			ID:       r.UserID,
+			Username: r.User.Username,
+			Avatar:   r.User.Avatar,
+		},
+		OwnerReply:     r.OwnerReply,
+		OwnerRepliedAt: r.OwnerRepliedAt,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+}
@@ -0,0 +1,70 @@
+// Package dto holds the response shapes handlers send to clients. Models
+// are mapped explicitly so that credentials and private fields can only
+// reach a response by being added here on purpose.
+package dto
+
+import (
+	"ai-agent-hub/internal/models"
+	"time"
+)
+
+// PublicProfile is what anyone can see about a user.
+type PublicProfile struct {
+	ID         uint      `json:"id"`
+	Username   string    `json:"username"`
+	Bio        string    `json:"bio"`
+	Avatar     string    `json:"avatar"`
+	AgentCount int64     `json:"agentCount"`
+	JoinedAt   time.Time `json:"joinedAt"`
+}
+
+// MyProfile is the authenticated user's view of their own account.
+type MyProfile struct {
+	PublicProfile
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"emailVerified"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func NewPublicProfile(u models.User, agentCount int64) PublicProfile {
+	return PublicProfile{
+		ID:         u.ID,
+		Username:   u.Username,
+		Bio:        u.Bio,
+		Avatar:     u.Avatar,
+		AgentCount: agentCount,
+		JoinedAt:   u.CreatedAt,
+	}
+}
+
+func NewMyProfile(u models.User, agentCount int64) MyProfile {
+	return MyProfile{
+		PublicProfile: NewPublicProfile(u, agentCount),
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified(),
+		UpdatedAt:     u.UpdatedAt,
+	}
+}
+
+// AdminUser is the account view shown to admins.
+type AdminUser struct {
+	ID            uint      `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	Role          string    `json:"role"`
+	EmailVerified bool      `json:"emailVerified"`
+	Disabled      bool      `json:"disabled"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+func NewAdminUser(u models.User) AdminUser {
+	return AdminUser{
+		ID:            u.ID,
+		Username:      u.Username,
+		Email:         u.Email,
+		Role:          u.Role,
+		EmailVerified: u.EmailVerified(),
+		Disabled:      u.Disabled(),
+		CreatedAt:     u.CreatedAt,
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/auth"
+	"ai-agent-hub/internal/mail"
+	"ai-agent-hub/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ========== EMAIL VERIFICATION & PASSWORD RESET ==========
+
+// sendVerificationEmail issues a verification token and queues the email.
+func sendVerificationEmail(tx *gorm.DB, user models.User) error {
+	token, err := auth.IssueActionToken(tx, user.ID, auth.PurposeVerifyEmail, auth.VerifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	return mail.Enqueue(tx, mail.VerificationEmail(user.Email, user.Username, token))
+}
+
+// POST /api/auth/verify-email
+func (h *Handler) VerifyEmail(c echo.Context) error {
+	var req struct {
+		Token string `json:"token" validate:"required"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	userID, err := auth.ConsumeActionToken(h.DB, req.Token, auth.PurposeVerifyEmail)
+	if err != nil {
+		if err == auth.ErrInvalidActionToken {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid or expired token"})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Database error"})
+	}
+
+	if err := h.DB.Model(&models.User{}).Where("id = ? AND email_verified_at IS NULL", userID).
+		Update("email_verified_at", time.Now()).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to verify email"})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Email verified"})
+}
+
+// POST /api/my/resend-verification
+func (h *Handler) ResendVerification(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, userID).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "User not found"})
+	}
+	if user.EmailVerified() {
+		return c.JSON(http.StatusConflict, echo.Map{"error": "Email is already verified"})
+	}
+
+	if err := h.DB.Transaction(func(tx *gorm.DB) error {
+		return sendVerificationEmail(tx, user)
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to send verification email"})
+	}
+
+	return c.JSON(http.StatusAccepted, echo.Map{"message": "Verification email sent"})
+}
+
+// POST /api/auth/forgot-password
+//
+// Always answers 202 so the endpoint cannot be used to discover which
+// emails have accounts.
+func (h *Handler) ForgotPassword(c echo.Context) error {
+	var req struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	accepted := echo.Map{"message": "If the email is registered, a reset link has been sent"}
+
+	var user models.User
+	if err := h.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusAccepted, accepted)
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Database error"})
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := auth.IssueActionToken(tx, user.ID, auth.PurposeResetPassword, auth.ResetPasswordTTL)
+		if err != nil {
+			return err
+		}
+		return mail.Enqueue(tx, mail.PasswordResetEmail(user.Email, user.Username, token))
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to send reset email"})
+	}
+
+	return c.JSON(http.StatusAccepted, accepted)
+}
+
+// POST /api/auth/reset-password
+func (h *Handler) ResetPassword(c echo.Context) error {
+	var req struct {
+		Token    string `json:"token" validate:"required"`
+		Password string `json:"password" validate:"required,min=6"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to hash password"})
+	}
+
+	var userID uint
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if userID, err = auth.ConsumeActionToken(tx, req.Token, auth.PurposeResetPassword); err != nil {
+			return err
+		}
+
+		// Receiving the reset link proves ownership of the address too.
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]any{
+			"password":          string(hashed),
+			"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", time.Now()),
+		}).Error; err != nil {
+			return err
+		}
+
+		return auth.RevokeUserSessions(tx, userID)
+	})
+	if err != nil {
+		if err == auth.ErrInvalidActionToken {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid or expired token"})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to reset password"})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Password has been reset, please log in again"})
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/auth"
+	"ai-agent-hub/internal/dto"
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/similar"
+	"ai-agent-hub/internal/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ========== ADMIN ==========
+
+// logModeration records an admin or moderator action in the audit log.
+func logModeration(tx *gorm.DB, actorID uint, action, targetType string, targetID uint, reason string) error {
+	return tx.Create(&models.ModerationAction{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+	}).Error
+}
+
+var adminUserListSpec = utils.ListSpec{
+	Sorts: map[string]string{
+		"id":         "id",
+		"created_at": "created_at",
+		"username":   "username",
+	},
+	DefaultSort: "id",
+	Filters: map[string]utils.Filter{
+		"role": {Column: "role", Kind: utils.FilterEquals},
+	},
+	DateRanges: map[string]string{
+		"created_at": "created_at",
+	},
+	Key:   "id",
+	Model: &models.User{},
+}
+
+// GET /api/admin/users?q=
+func (h *Handler) AdminListUsers(c echo.Context) error {
+	lq, err := utils.ParseListQuery(c, adminUserListSpec)
+	if err != nil {
+		return err
+	}
+	q := strings.TrimSpace(c.QueryParam("q"))
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		if q != "" {
+			like := "%" + q + "%"
+			db = db.Where("username ILIKE ? OR email ILIKE ?", like, like)
+		}
+		return db.Scopes(lq.Where)
+	}
+
+	var total int64
+	if err := h.DB.Model(&models.User{}).Scopes(scope).Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch users"})
+	}
+
+	var users []models.User
+	if err := h.DB.Scopes(scope, lq.Paginate).Find(&users).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch users"})
+	}
+
+	users, page := utils.FinishPage(lq, users)
+
+	out := make([]dto.AdminUser, len(users))
+	for i, u := range users {
+		out[i] = dto.NewAdminUser(u)
+	}
+
+	resp := lq.Response(out, page, total)
+	utils.SetLinkHeader(c, resp)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// PUT /api/admin/users/:id/role
+//
+// The role is also carried in access tokens, so changing it revokes all of
+// the user's sessions and they have to sign in again.
+func (h *Handler) AdminSetUserRole(c echo.Context) error {
+	actorID, _ := currentUserID(c)
+
+	var req struct {
+		Role string `json:"role" validate:"required"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if !models.ValidRole(req.Role) {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Unknown role"})
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "User not found"})
+	}
+	if user.ID == actorID {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "You cannot change your own role"})
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("role", req.Role).Error; err != nil {
+			return err
+		}
+		if err := auth.RevokeUserSessions(tx, user.ID); err != nil {
+			return err
+		}
+		return logModeration(tx, actorID, "set_role:"+req.Role, "user", user.ID, "")
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to update role"})
+	}
+
+	return c.JSON(http.StatusOK, dto.NewAdminUser(user))
+}
+
+// PUT /api/admin/users/:id/status
+//
+// Disabling a user also revokes all of their sessions.
+func (h *Handler) AdminSetUserStatus(c echo.Context) error {
+	actorID, _ := currentUserID(c)
+
+	var req struct {
+		Disabled bool   `json:"disabled"`
+		Reason   string `json:"reason"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "User not found"})
+	}
+	if user.ID == actorID {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "You cannot disable yourself"})
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if req.Disabled {
+			now := time.Now()
+			user.DisabledAt = &now
+			if err := auth.RevokeUserSessions(tx, user.ID); err != nil {
+				return err
+			}
+		} else {
+			user.DisabledAt = nil
+		}
+		if err := tx.Model(&user).Update("disabled_at", user.DisabledAt).Error; err != nil {
+			return err
+		}
+
+		action := "enable_user"
+		if req.Disabled {
+			action = "disable_user"
+		}
+		return logModeration(tx, actorID, action, "user", user.ID, req.Reason)
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to update user"})
+	}
+
+	return c.JSON(http.StatusOK, dto.NewAdminUser(user))
+}
+
+// PUT /api/admin/agents/:id/featured
+func (h *Handler) AdminSetAgentFeatured(c echo.Context) error {
+	actorID, _ := currentUserID(c)
+
+	var req struct {
+		Featured bool `json:"featured"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+
+	var agent models.Agent
+	if err := h.DB.First(&agent, "id = ?", c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "Agent not found"})
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&agent).Update("is_featured", req.Featured).Error; err != nil {
+			return err
+		}
+		action := "unfeature_agent"
+		if req.Featured {
+			action = "feature_agent"
+		}
+		return logModeration(tx, actorID, action, "agent", agent.ID, "")
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to update agent"})
+	}
+
+	return c.JSON(http.StatusOK, agent)
+}
+
+// DELETE /api/admin/agents/:id?reason=
+func (h *Handler) AdminDeleteAgent(c echo.Context) error {
+	actorID, _ := currentUserID(c)
+
+	var agent models.Agent
+	if err := h.DB.First(&agent, "id = ?", c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "Agent not found"})
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&agent).Error; err != nil {
+			return err
+		}
+		return logModeration(tx, actorID, "delete_agent", "agent", agent.ID, c.QueryParam("reason"))
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to delete agent"})
+	}
+
+	similar.MarkDirty(agent.ID)
+	return c.NoContent(http.StatusNoContent)
+}
+
+var moderationLogListSpec = utils.ListSpec{
+	Sorts: map[string]string{
+		"id":         "id",
+		"created_at": "created_at",
+	},
+	DefaultSort: "-id",
+	Filters: map[string]utils.Filter{
+		"actor_id":    {Column: "actor_id", Kind: utils.FilterNumber},
+		"action":      {Column: "action", Kind: utils.FilterEquals},
+		"target_type": {Column: "target_type", Kind: utils.FilterEquals},
+		"target_id":   {Column: "target_id", Kind: utils.FilterNumber},
+	},
+	DateRanges: map[string]string{
+		"created_at": "created_at",
+	},
+	Key:   "id",
+	Model: &models.ModerationAction{},
+}
+
+// GET /api/admin/moderation-log
+func (h *Handler) AdminModerationLog(c echo.Context) error {
+	lq, err := utils.ParseListQuery(c, moderationLogListSpec)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	if err := h.DB.Model(&models.ModerationAction{}).Scopes(lq.Where).Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch moderation log"})
+	}
+
+	var actions []models.ModerationAction
+	if err := h.DB.Scopes(lq.Where, lq.Paginate).Find(&actions).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch moderation log"})
+	}
+
+	actions, page := utils.FinishPage(lq, actions)
+
+	resp := lq.Response(actions, page, total)
+	utils.SetLinkHeader(c, resp)
+	return c.JSON(http.StatusOK, resp)
+}
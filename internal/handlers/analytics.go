@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/analytics"
+	"ai-agent-hub/internal/dto"
+	"ai-agent-hub/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== ANALYTICS ==========
+
+const (
+	defaultAnalyticsDays = 30
+	maxAnalyticsDays     = 366
+)
+
+// viewerKey identifies who is looking at an agent for view de-duplication:
+// the account when signed in, otherwise a hash of IP and user agent. The IP
+// only honours forwarding headers from trusted proxies, see ipExtractor in
+// cmd/main.go.
+func viewerKey(c echo.Context) string {
+	if userID, ok := currentUserID(c); ok {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	sum := sha256.Sum256([]byte(c.RealIP() + "|" + c.Request().UserAgent()))
+	return "anon:" + hex.EncodeToString(sum[:16])
+}
+
+// recordView counts a view of agent unless it is the owner looking at
+// their own agent. Failures are logged; they never fail the request.
+func (h *Handler) recordView(c echo.Context, agent *models.Agent) {
+	if userID, ok := currentUserID(c); ok && userID == agent.UserID {
+		return
+	}
+
+	counted, err := analytics.RecordView(h.DB, agent.ID, viewerKey(c))
+	if err != nil {
+		c.Logger().Errorf("record view: %v", err)
+		return
+	}
+	if counted {
+		agent.ViewCount++
+	}
+}
+
+// GET /api/my/agents/:id/analytics?from=&to=&days=
+//
+// Daily views, unique viewers and chats, zero-filled. Defaults to the last
+// 30 days; from/to are YYYY-MM-DD (UTC). Daily figures are refreshed every
+// few minutes by the analytics roll-up.
+func (h *Handler) GetMyAgentAnalytics(c echo.Context) error {
+	agent, err := h.findMyAgent(c)
+	if err != nil {
+		return err
+	}
+
+	from, to, err := analyticsRange(c)
+	if err != nil {
+		return err
+	}
+
+	var stats []models.AgentDailyStat
+	if err := h.DB.Where("agent_id = ? AND day BETWEEN ? AND ?", agent.ID, from, to).
+		Find(&stats).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch analytics"})
+	}
+	byDay := make(map[string]models.AgentDailyStat, len(stats))
+	for _, s := range stats {
+		byDay[s.Day.Format(time.DateOnly)] = s
+	}
+
+	resp := dto.AgentAnalytics{
+		AgentID:      agent.ID,
+		From:         from.Format(time.DateOnly),
+		To:           to.Format(time.DateOnly),
+		AllTimeViews: agent.ViewCount,
+		Series:       []dto.DailyStat{},
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(time.DateOnly)
+		s := byDay[key]
+		resp.Series = append(resp.Series, dto.DailyStat{
+			Date:          key,
+			Views:         s.Views,
+			UniqueViewers: s.UniqueViewers,
+			Chats:         s.Chats,
+		})
+		resp.Totals.Views += s.Views
+		resp.Totals.Chats += s.Chats
+	}
+
+	// Raw events are only kept for analytics.Retention, so distinct viewers
+	// over older ranges only cover what is left.
+	if err := h.DB.Model(&models.AgentEvent{}).
+		Where("agent_id = ? AND kind = ? AND created_at >= ? AND created_at < ?",
+			agent.ID, models.EventView, from, to.AddDate(0, 0, 1)).
+		Distinct("viewer_key").Count(&resp.Totals.UniqueViewers).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch analytics"})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func analyticsRange(c echo.Context) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if s := c.QueryParam("to"); s != "" {
+		t, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid to date, expected YYYY-MM-DD")
+		}
+		to = t
+	}
+
+	days := defaultAnalyticsDays
+	if s := c.QueryParam("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid days")
+		}
+		days = n
+	}
+	from := to.AddDate(0, 0, -(days - 1))
+
+	if s := c.QueryParam("from"); s != "" {
+		t, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid from date, expected YYYY-MM-DD")
+		}
+		from = t
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "from must not be after to")
+	}
+	if to.Sub(from) >= maxAnalyticsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Range is limited to %d days", maxAnalyticsDays))
+	}
+	return from, to, nil
+}
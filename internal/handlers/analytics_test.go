@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/analytics"
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestViewsAreCountedOncePerViewer(t *testing.T) {
+	h := newTestHandler(t, &llm.EchoProvider{})
+	owner := createTestUser(t, h.DB, "owner")
+	alice := createTestUser(t, h.DB, "alice")
+	bob := createTestUser(t, h.DB, "bob")
+	agent := createTestAgent(t, h.DB, models.Agent{Name: "Popular", UserID: owner.ID})
+
+	for _, viewer := range []uint{alice.ID, alice.ID, bob.ID, owner.ID, 0, 0} {
+		c, rec := newTestContext(http.MethodGet, "/", "", viewer, "id", idParam(agent.ID))
+		if err := h.GetAgentsByID(c); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("GetAgentsByID: %v, status %d", err, rec.Code)
+		}
+	}
+
+	// Alice, Bob and one anonymous viewer; the owner's views don't count.
+	var after models.Agent
+	h.DB.First(&after, agent.ID)
+	if after.ViewCount != 3 {
+		t.Errorf("viewCount = %d, want 3", after.ViewCount)
+	}
+
+	// Chats are never de-duplicated.
+	for i := 0; i < 2; i++ {
+		if err := analytics.RecordChat(h.DB, agent.ID, "user:1"); err != nil {
+			t.Fatalf("RecordChat: %v", err)
+		}
+	}
+	var chats int64
+	h.DB.Model(&models.AgentEvent{}).Where("kind = ?", models.EventChat).Count(&chats)
+	if chats != 2 {
+		t.Errorf("recorded %d chats, want 2", chats)
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/auth"
+	"ai-agent-hub/internal/dto"
+	"ai-agent-hub/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== API KEYS ==========
+
+// maxAPIKeysPerUser limits how many active keys a user can hold.
+const maxAPIKeysPerUser = 20
+
+// GET /api/my/api-keys
+func (h *Handler) GetMyAPIKeys(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	var keys []models.APIKey
+	if err := h.DB.Where("user_id = ?", userID).Order("id desc").Find(&keys).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch API keys"})
+	}
+
+	out := make([]dto.APIKey, len(keys))
+	for i, k := range keys {
+		out[i] = dto.NewAPIKey(k)
+	}
+	return c.JSON(http.StatusOK, echo.Map{"data": out, "availableScopes": auth.APIKeyScopes})
+}
+
+// POST /api/my/api-keys
+func (h *Handler) CreateMyAPIKey(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	type CreateAPIKeyRequest struct {
+		Name          string   `json:"name" validate:"required,max=64"`
+		Scopes        []string `json:"scopes" validate:"required,min=1"`
+		ExpiresInDays int      `json:"expiresInDays" validate:"min=0,max=3650"`
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	for _, s := range req.Scopes {
+		if !auth.ValidScope(s) {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "Unknown scope: " + s})
+		}
+	}
+
+	var active int64
+	if err := h.DB.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&active).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Database error"})
+	}
+	if active >= maxAPIKeysPerUser {
+		return c.JSON(http.StatusConflict, echo.Map{"error": "Too many active API keys, revoke one first"})
+	}
+
+	raw, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to generate API key"})
+	}
+
+	key := models.APIKey{
+		UserID:  userID,
+		Name:    strings.TrimSpace(req.Name),
+		Prefix:  prefix,
+		KeyHash: hash,
+		Scopes:  strings.Join(req.Scopes, " "),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := h.DB.Create(&key).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to create API key"})
+	}
+
+	return c.JSON(http.StatusCreated, dto.CreatedAPIKey{APIKey: dto.NewAPIKey(key), Key: raw})
+}
+
+// DELETE /api/my/api-keys/:id
+func (h *Handler) RevokeMyAPIKey(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	result := h.DB.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", c.Param("id"), userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to revoke API key"})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "API key not found"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
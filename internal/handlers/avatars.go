@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/dto"
+	"ai-agent-hub/internal/imaging"
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ========== AVATARS & MEDIA ==========
+
+const (
+	maxAvatarUpload = 5 << 20
+	// defaultAvatarSize is the thumbnail stored in the Avatar field.
+	defaultAvatarSize = 256
+)
+
+// mediaURL is the public URL GET /api/media serves a stored key at.
+func mediaURL(key string) string {
+	base := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	return base + "/api/media/" + key
+}
+
+// readAvatarUpload reads the "avatar" multipart field, refusing bodies
+// over maxAvatarUpload before they are buffered.
+func readAvatarUpload(c echo.Context) ([]byte, error) {
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, maxAvatarUpload+64<<10)
+
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Avatar must be at most 5 MB")
+		}
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Missing avatar file")
+	}
+	if file.Size > maxAvatarUpload {
+		return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Avatar must be at most 5 MB")
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Could not read avatar file")
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxAvatarUpload+1))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Could not read avatar file")
+	}
+	if len(data) > maxAvatarUpload {
+		return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Avatar must be at most 5 MB")
+	}
+	return data, nil
+}
+
+// storeAvatar resizes an upload and stores every thumbnail under
+// avatars/<owner>/<content hash>-<size>.png, returning the key prefix before
+// "-<size>.png". Keys change with the content, so the files can be cached
+// forever.
+func (h *Handler) storeAvatar(ctx context.Context, owner string, data []byte) (dto.Avatar, string, error) {
+	thumbs, err := imaging.Avatar(data)
+	if err != nil {
+		return dto.Avatar{}, "", echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	sum := sha256.Sum256(data)
+	base := fmt.Sprintf("avatars/%s/%s", owner, hex.EncodeToString(sum[:8]))
+
+	avatar := dto.Avatar{Sizes: map[string]string{}}
+	for _, size := range imaging.AvatarSizes {
+		key := fmt.Sprintf("%s-%d.png", base, size)
+		if err := h.Storage.Put(ctx, key, thumbs[size], "image/png"); err != nil {
+			return dto.Avatar{}, "", err
+		}
+		avatar.Sizes[strconv.Itoa(size)] = mediaURL(key)
+	}
+	avatar.URL = avatar.Sizes[strconv.Itoa(defaultAvatarSize)]
+	return avatar, base, nil
+}
+
+// removeUserAvatar deletes the thumbnails of a user's previous upload. The
+// key comes from the user's own AvatarKey, never from the Avatar URL, which
+// users can set freely; it is checked to be under their own prefix anyway.
+// Failures only leave orphaned files, so they are logged.
+func (h *Handler) removeUserAvatar(c echo.Context, userID uint, base string) {
+	if base == "" || !strings.HasPrefix(base, fmt.Sprintf("avatars/users/%d/", userID)) {
+		return
+	}
+
+	for _, size := range imaging.AvatarSizes {
+		key := fmt.Sprintf("%s-%d.png", base, size)
+		if err := h.Storage.Delete(c.Request().Context(), key); err != nil {
+			c.Logger().Errorf("delete old avatar %s: %v", key, err)
+		}
+	}
+}
+
+// PUT /api/my/profile/avatar (multipart, field "avatar")
+func (h *Handler) UploadProfileAvatar(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, userID).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "User not found"})
+	}
+
+	data, err := readAvatarUpload(c)
+	if err != nil {
+		return err
+	}
+	avatar, key, err := h.storeAvatar(c.Request().Context(), fmt.Sprintf("users/%d", user.ID), data)
+	if err != nil {
+		return err
+	}
+
+	oldKey := user.AvatarKey
+	if err := h.DB.Model(&user).Updates(map[string]any{"avatar": avatar.URL, "avatar_key": key}).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to update avatar"})
+	}
+	if oldKey != key {
+		h.removeUserAvatar(c, user.ID, oldKey)
+	}
+
+	return c.JSON(http.StatusOK, avatar)
+}
+
+// PUT /api/my/agents/:id/avatar (multipart, field "avatar")
+func (h *Handler) UploadAgentAvatar(c echo.Context) error {
+	agent, err := h.findMyAgent(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := readAvatarUpload(c)
+	if err != nil {
+		return err
+	}
+	avatar, _, err := h.storeAvatar(c.Request().Context(), fmt.Sprintf("agents/%d", agent.ID), data)
+	if err != nil {
+		return err
+	}
+
+	// The previous avatar is kept: older versions still point at it and a
+	// rollback would restore it.
+	agentBefore := agent
+	agent.Avatar = avatar.URL
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureInitialVersion(tx, agentBefore); err != nil {
+			return err
+		}
+		if err := tx.Model(&agent).Update("avatar", agent.Avatar).Error; err != nil {
+			return err
+		}
+		return recordAgentVersion(tx, agent, agent.UserID, "Avatar updated")
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to update avatar"})
+	}
+
+	return c.JSON(http.StatusOK, avatar)
+}
+
+// GET /api/media/*
+func (h *Handler) ServeMedia(c echo.Context) error {
+	key := c.Param("*")
+	if !storage.ValidKey(key) {
+		return echo.NewHTTPError(http.StatusNotFound, "Not found")
+	}
+
+	// Stored keys embed a content hash, so the key itself is a strong ETag.
+	etag := `"` + key + `"`
+	header := c.Response().Header()
+	header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	header.Set("ETag", etag)
+	if c.Request().Header.Get("If-None-Match") == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	body, obj, err := h.Storage.Get(c.Request().Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		header.Del("Cache-Control")
+		header.Del("ETag")
+		return echo.NewHTTPError(http.StatusNotFound, "Not found")
+	}
+	if err != nil {
+		header.Del("Cache-Control")
+		header.Del("ETag")
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to read file"})
+	}
+	defer body.Close()
+
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Security-Policy", "default-src 'none'")
+	if obj.Size > 0 {
+		header.Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+	}
+	return c.Stream(http.StatusOK, contentType, body)
+}
+
+const (
+	maxIdenticonSeed        = 128
+	defaultIdenticonPNGSize = 256
+)
+
+// identiconURL is the default avatar for something without an upload.
+func identiconURL(seed string) string {
+	return strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/") + imaging.IdenticonPath(seed)
+}
+
+// GET /api/avatars/:seed (.svg, the default, or .png?size=)
+func (h *Handler) GetIdenticon(c echo.Context) error {
+	seed, err := url.PathUnescape(c.Param("seed"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid seed")
+	}
+	format := "svg"
+	if s, ok := strings.CutSuffix(seed, ".png"); ok {
+		seed, format = s, "png"
+	} else {
+		seed = strings.TrimSuffix(seed, ".svg")
+	}
+	if seed == "" || len(seed) > maxIdenticonSeed {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid seed")
+	}
+
+	ic := imaging.NewIdenticon(seed)
+	header := c.Response().Header()
+	header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	if format == "svg" {
+		header.Set("Content-Security-Policy", "default-src 'none'")
+		return c.Blob(http.StatusOK, "image/svg+xml", ic.SVG())
+	}
+
+	size := defaultIdenticonPNGSize
+	if s := c.QueryParam("size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 16 || n > 1024 {
+			header.Del("Cache-Control")
+			return echo.NewHTTPError(http.StatusBadRequest, "size must be between 16 and 1024")
+		}
+		size = n
+	}
+	data, err := ic.PNG(size)
+	if err != nil {
+		header.Del("Cache-Control")
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to render identicon"})
+	}
+	return c.Blob(http.StatusOK, "image/png", data)
+}
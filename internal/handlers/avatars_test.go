@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/storage"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func testPNG(t *testing.T, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for x := 0; x < 32; x++ {
+		for y := 0; y < 32; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func uploadProfileAvatar(t *testing.T, h *Handler, userID uint, data []byte) {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, _ := w.CreateFormFile("avatar", "avatar.png")
+	part.Write(data)
+	w.Close()
+
+	c, rec := newTestContext(http.MethodPut, "/", body.String(), userID)
+	c.Request().Header.Set("Content-Type", w.FormDataContentType())
+	if err := h.UploadProfileAvatar(c); err != nil {
+		t.Fatalf("UploadProfileAvatar: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("UploadProfileAvatar status = %d, body %s", rec.Code, rec.Body)
+	}
+}
+
+func storedKeys(t *testing.T, s storage.Storage, base string) int {
+	t.Helper()
+	n := 0
+	for _, size := range []int{64, 256, 512} {
+		if body, _, err := s.Get(context.Background(), fmt.Sprintf("%s-%d.png", base, size)); err == nil {
+			body.Close()
+			n++
+		}
+	}
+	return n
+}
+
+func TestUploadProfileAvatarOnlyRemovesOwnFiles(t *testing.T) {
+	t.Setenv("PUBLIC_BASE_URL", "http://hub.test")
+	h := newTestHandler(t, &llm.EchoProvider{})
+	h.Storage = storage.NewLocalStorage(t.TempDir())
+	victim := createTestUser(t, h.DB, "victim")
+	attacker := createTestUser(t, h.DB, "attacker")
+
+	uploadProfileAvatar(t, h, victim.ID, testPNG(t, color.RGBA{R: 255, A: 255}))
+	h.DB.First(&victim, victim.ID)
+	if victim.AvatarKey == "" || storedKeys(t, h.Storage, victim.AvatarKey) != 3 {
+		t.Fatalf("victim's upload not stored: key %q", victim.AvatarKey)
+	}
+
+	// The attacker points their avatar at the victim's files, then uploads.
+	c, rec := newTestContext(http.MethodPut, "/", `{"avatar":"`+victim.Avatar+`"}`, attacker.ID)
+	if err := h.UpdateProfile(c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("UpdateProfile: %v, status %d", err, rec.Code)
+	}
+	uploadProfileAvatar(t, h, attacker.ID, testPNG(t, color.RGBA{B: 255, A: 255}))
+
+	if n := storedKeys(t, h.Storage, victim.AvatarKey); n != 3 {
+		t.Errorf("victim has %d of 3 thumbnails left", n)
+	}
+
+	// Replacing one's own upload still cleans up the old files.
+	h.DB.First(&attacker, attacker.ID)
+	oldKey := attacker.AvatarKey
+	uploadProfileAvatar(t, h, attacker.ID, testPNG(t, color.RGBA{G: 255, A: 255}))
+	if n := storedKeys(t, h.Storage, oldKey); n != 0 {
+		t.Errorf("%d old thumbnails left after replacing own avatar", n)
+	}
+
+	var after models.User
+	h.DB.First(&after, attacker.ID)
+	if after.AvatarKey == oldKey || storedKeys(t, h.Storage, after.AvatarKey) != 3 {
+		t.Errorf("new avatar key %q not stored", after.AvatarKey)
+	}
+}
+
+func TestAvatarURLsMustBeHTTP(t *testing.T) {
+	h := newTestHandler(t, &llm.EchoProvider{})
+	user := createTestUser(t, h.DB, "mallory")
+	agent := createTestAgent(t, h.DB, models.Agent{Name: "Mine", UserID: user.ID})
+
+	calls := []struct {
+		name   string
+		call   func(echo.Context) error
+		userID uint
+		body   string
+	}{
+		{"Register", h.Register, 0, `{"username":"eve","email":"eve@example.com","password":"secret1","avatar":%q}`},
+		{"UpdateProfile", h.UpdateProfile, user.ID, `{"avatar":%q}`},
+		{"CreateMyAgents", h.CreateMyAgents, user.ID, `{"name":"New","system_prompt":"Hi.","avatar":%q}`},
+		{"UpdateMyAgent", h.UpdateMyAgent, user.ID, `{"name":"Mine","system_prompt":"Hi.","avatar":%q}`},
+	}
+	bad := []string{"javascript:alert(1)", "data:image/svg+xml,<svg/>", "//evil.test/a.png", "https://user:pw@evil.test/a.png"}
+
+	for _, tc := range calls {
+		for _, avatar := range bad {
+			c, rec := newTestContext(http.MethodPost, "/", fmt.Sprintf(tc.body, avatar), tc.userID, "id", idParam(agent.ID))
+			if err := tc.call(c); err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s with avatar %q: status = %d, want 400", tc.name, avatar, rec.Code)
+			}
+		}
+	}
+
+	for _, tc := range calls {
+		c, rec := newTestContext(http.MethodPost, "/", fmt.Sprintf(tc.body, "https://cdn.example.com/a.png"), tc.userID, "id", idParam(agent.ID))
+		if err := tc.call(c); err != nil || rec.Code >= 300 {
+			t.Errorf("%s with an https avatar: %v, status %d, body %s", tc.name, err, rec.Code, rec.Body)
+		}
+	}
+}
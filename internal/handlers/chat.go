@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/analytics"
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/prompt"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ========== CHAT ==========
+
+// maxHistoryMessages caps how many earlier turns are replayed to the provider.
+const maxHistoryMessages = 20
+
+type ChatRequest struct {
+	Message        string            `json:"message" validate:"required_without=Variables"`
+	Variables      map[string]string `json:"variables"`
+	ConversationID uint              `json:"conversationId"`
+}
+
+// buildChatRequest assembles the provider request for an agent: the system
+// prompt and personality become the system message, earlier turns of the
+// conversation follow in order, and the rendered input comes last.
+func buildChatRequest(agent models.Agent, history []models.Message, input string) llm.Request {
+	var system strings.Builder
+	system.WriteString(strings.TrimSpace(agent.SystemPrompt))
+	if p := strings.TrimSpace(agent.Personality); p != "" {
+		if system.Len() > 0 {
+			system.WriteString("\n\n")
+		}
+		system.WriteString("Personality: " + p)
+	}
+
+	var messages []llm.Message
+	if system.Len() > 0 {
+		messages = append(messages, llm.Message{Role: llm.RoleSystem, Content: system.String()})
+	}
+	for _, m := range history {
+		messages = append(messages, llm.Message{Role: m.Role, Content: m.Content})
+	}
+	messages = append(messages, llm.Message{Role: llm.RoleUser, Content: input})
+
+	return llm.Request{Messages: messages}
+}
+
+// renderInput renders the agent's input template with the request's
+// variables. The chat message is available to the template as {{input}};
+// an empty template passes the message through unchanged.
+func renderInput(tmpl, message string, variables map[string]string) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		if message == "" {
+			return "", echo.NewHTTPError(http.StatusBadRequest, "message is required")
+		}
+		return message, nil
+	}
+
+	t, err := prompt.Parse(tmpl)
+	if err != nil {
+		return "", echo.NewHTTPError(http.StatusUnprocessableEntity, "Agent input template is invalid: "+err.Error())
+	}
+
+	vars := make(map[string]string, len(variables)+1)
+	for k, v := range variables {
+		vars[k] = v
+	}
+	if message != "" {
+		vars["input"] = message
+	}
+
+	out, err := t.Render(vars)
+	if err != nil {
+		return "", echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return out, nil
+}
+
+// conversationTitle derives a short title from the first message.
+func conversationTitle(message string) string {
+	title := strings.Join(strings.Fields(message), " ")
+	if utf8.RuneCountInString(title) > 60 {
+		title = string([]rune(title)[:60]) + "…"
+	}
+	return title
+}
+
+// chatSession is everything needed to run one chat turn.
+type chatSession struct {
+	Agent        models.Agent
+	Conversation models.Conversation
+	History      []models.Message
+	// Message is what gets stored for the user's turn; Input is what the
+	// provider sees after the input template has been applied.
+	Message string
+	Input   string
+}
+
+// bindChat loads the agent addressed by the route, validates the chat
+// request body and, when a conversation is being resumed, its history.
+func (h *Handler) bindChat(c echo.Context) (*chatSession, error) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	agent, err := h.findAgent(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var req ChatRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	if err := c.Validate(&req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	input, err := renderInput(agent.InputTemplate, req.Message, req.Variables)
+	if err != nil {
+		return nil, err
+	}
+	message := req.Message
+	if message == "" {
+		message = input
+	}
+
+	session := &chatSession{
+		Agent:        agent,
+		Conversation: models.Conversation{UserID: userID, AgentID: agent.ID, Title: conversationTitle(message)},
+		Message:      message,
+		Input:        input,
+	}
+	if req.ConversationID == 0 {
+		return session, nil
+	}
+
+	if err := h.DB.Where("id = ? AND user_id = ? AND agent_id = ?", req.ConversationID, userID, agent.ID).
+		First(&session.Conversation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, echo.NewHTTPError(http.StatusNotFound, "Conversation not found")
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Database error")
+	}
+
+	history, err := h.loadHistory(session.Conversation.ID)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to load conversation")
+	}
+	session.History = history
+
+	return session, nil
+}
+
+// loadHistory returns the most recent messages of a conversation, oldest first.
+func (h *Handler) loadHistory(conversationID uint) ([]models.Message, error) {
+	var history []models.Message
+	if err := h.DB.Where("conversation_id = ?", conversationID).
+		Order("id desc").Limit(maxHistoryMessages).Find(&history).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history, nil
+}
+
+// saveTurn persists the user's message and the agent's reply, creating the
+// conversation first if this is its opening turn, and counts the turn as a
+// chat for analytics.
+func (h *Handler) saveTurn(s *chatSession, reply string) error {
+	return h.DB.Transaction(func(tx *gorm.DB) error {
+		if s.Conversation.ID == 0 {
+			if err := tx.Create(&s.Conversation).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(&s.Conversation).Update("updated_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		messages := []models.Message{
+			{ConversationID: s.Conversation.ID, Role: llm.RoleUser, Content: s.Message},
+			{ConversationID: s.Conversation.ID, Role: llm.RoleAssistant, Content: reply},
+		}
+		if err := tx.Create(&messages).Error; err != nil {
+			return err
+		}
+
+		chatter := fmt.Sprintf("user:%d", s.Conversation.UserID)
+		if s.Conversation.LineUserID != "" {
+			chatter = "line:" + s.Conversation.LineUserID
+		}
+		return analytics.RecordChat(tx, s.Agent.ID, chatter)
+	})
+}
+
+// POST /api/agents/:id/chat
+func (h *Handler) ChatWithAgent(c echo.Context) error {
+	s, err := h.bindChat(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.LLM.Complete(c.Request().Context(), buildChatRequest(s.Agent, s.History, s.Input))
+	if err != nil {
+		c.Logger().Errorf("chat with agent %d: %v", s.Agent.ID, err)
+		return c.JSON(http.StatusBadGateway, echo.Map{"error": "Agent failed to respond"})
+	}
+
+	if err := h.saveTurn(s, resp.Content); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to save conversation"})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"agentId":        s.Agent.ID,
+		"conversationId": s.Conversation.ID,
+		"message":        s.Message,
+		"reply":          resp.Content,
+		"provider":       h.LLM.Name(),
+		"model":          resp.Model,
+	})
+}
+
+// POST /api/agents/:id/chat/stream
+//
+// Streams the reply as Server-Sent Events: a "delta" event per chunk,
+// followed by either "done" with the full reply or "error".
+func (h *Handler) StreamChatWithAgent(c echo.Context) error {
+	s, err := h.bindChat(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	sse := newSSEWriter(c)
+
+	resp, err := llm.Stream(ctx, h.LLM, buildChatRequest(s.Agent, s.History, s.Input), func(delta string) error {
+		return sse.Event("delta", echo.Map{"content": delta})
+	})
+	if ctx.Err() != nil {
+		// The client went away; there is nobody left to tell.
+		return nil
+	}
+	if err != nil {
+		c.Logger().Errorf("stream chat with agent %d: %v", s.Agent.ID, err)
+		return sse.Event("error", echo.Map{"error": "Agent failed to respond"})
+	}
+
+	if err := h.saveTurn(s, resp.Content); err != nil {
+		return sse.Event("error", echo.Map{"error": "Failed to save conversation"})
+	}
+
+	return sse.Event("done", echo.Map{
+		"agentId":        s.Agent.ID,
+		"conversationId": s.Conversation.ID,
+		"reply":          resp.Content,
+		"provider":       h.LLM.Name(),
+		"model":          resp.Model,
+	})
+}
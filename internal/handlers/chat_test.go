@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/utils"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// recordingProvider answers like the echo provider and keeps the last
+// request it was sent.
+type recordingProvider struct {
+	llm.EchoProvider
+	last llm.Request
+}
+
+func (p *recordingProvider) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	p.last = req
+	return p.EchoProvider.Complete(ctx, req)
+}
+
+func TestChatWithAgentSendsPromptPersonalityAndRenderedInput(t *testing.T) {
+	provider := &recordingProvider{}
+	h := newTestHandler(t, provider)
+	user := createTestUser(t, h.DB, "alice")
+	agent := createTestAgent(t, h.DB, models.Agent{
+		Name:          "Translator",
+		SystemPrompt:  "You translate text.",
+		Personality:   "pedantic",
+		InputTemplate: "Translate into {{lang|French}}: {{input}}",
+		UserID:        user.ID,
+	})
+
+	c, rec := newTestContext(http.MethodPost, "/", `{"message":"good morning","variables":{"lang":"German"}}`,
+		user.ID, "id", idParam(agent.ID))
+	if err := h.ChatWithAgent(c); err != nil {
+		t.Fatalf("ChatWithAgent: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	want := []llm.Message{
+		{Role: llm.RoleSystem, Content: "You translate text.\n\nPersonality: pedantic"},
+		{Role: llm.RoleUser, Content: "Translate into German: good morning"},
+	}
+	if len(provider.last.Messages) != len(want) {
+		t.Fatalf("provider got %d messages, want %d: %+v", len(provider.last.Messages), len(want), provider.last.Messages)
+	}
+	for i, m := range want {
+		if provider.last.Messages[i] != m {
+			t.Errorf("message %d = %+v, want %+v", i, provider.last.Messages[i], m)
+		}
+	}
+
+	var body struct {
+		Reply          string `json:"reply"`
+		Message        string `json:"message"`
+		Provider       string `json:"provider"`
+		ConversationID uint   `json:"conversationId"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if want := "[You translate text.] Echo: Translate into German: good morning"; body.Reply != want {
+		t.Errorf("reply = %q, want %q", body.Reply, want)
+	}
+	if body.Message != "good morning" || body.Provider != "echo" {
+		t.Errorf("message = %q, provider = %q", body.Message, body.Provider)
+	}
+
+	var stored []models.Message
+	h.DB.Where("conversation_id = ?", body.ConversationID).Order("id").Find(&stored)
+	if len(stored) != 2 || stored[0].Content != "good morning" || stored[1].Content != body.Reply {
+		t.Errorf("stored messages = %+v", stored)
+	}
+}
+
+func TestChatWithAgentUsesTemplateDefaultsAndReplaysHistory(t *testing.T) {
+	provider := &recordingProvider{}
+	h := newTestHandler(t, provider)
+	user := createTestUser(t, h.DB, "bob")
+	agent := createTestAgent(t, h.DB, models.Agent{
+		Name:          "Translator",
+		SystemPrompt:  "You translate text.",
+		InputTemplate: "Translate into {{lang|French}}: {{input}}",
+		UserID:        user.ID,
+	})
+
+	c, rec := newTestContext(http.MethodPost, "/", `{"message":"hello"}`, user.ID, "id", idParam(agent.ID))
+	if err := h.ChatWithAgent(c); err != nil {
+		t.Fatalf("first turn: %v", err)
+	}
+	var first struct {
+		ConversationID uint `json:"conversationId"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &first)
+
+	c, _ = newTestContext(http.MethodPost, "/", `{"message":"thanks","conversationId":`+idParam(first.ConversationID)+`}`,
+		user.ID, "id", idParam(agent.ID))
+	if err := h.ChatWithAgent(c); err != nil {
+		t.Fatalf("second turn: %v", err)
+	}
+
+	got := provider.last.Messages
+	if len(got) != 4 {
+		t.Fatalf("provider got %d messages, want 4: %+v", len(got), got)
+	}
+	if got[1].Role != llm.RoleUser || got[1].Content != "hello" {
+		t.Errorf("history user turn = %+v", got[1])
+	}
+	if got[2].Role != llm.RoleAssistant || got[2].Content != "[You translate text.] Echo: Translate into French: hello" {
+		t.Errorf("history assistant turn = %+v", got[2])
+	}
+	if got[3].Content != "Translate into French: thanks" {
+		t.Errorf("input = %q", got[3].Content)
+	}
+}
+
+type sseEvent struct {
+	Name string
+	Data string
+}
+
+// parseSSE splits a text/event-stream body into its events.
+func parseSSE(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	for _, block := range strings.Split(body, "\n\n") {
+		if block == "" {
+			continue
+		}
+		var ev sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				ev.Name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.Data = strings.TrimPrefix(line, "data: ")
+			default:
+				t.Fatalf("unexpected SSE line %q", line)
+			}
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func TestStreamChatWithAgentSendsDeltasThenDone(t *testing.T) {
+	h := newTestHandler(t, &llm.FakeProvider{Chunks: []string{"Hel", "lo", " there"}})
+	user := createTestUser(t, h.DB, "carol")
+	agent := createTestAgent(t, h.DB, models.Agent{Name: "Greeter", SystemPrompt: "Greet.", UserID: user.ID})
+
+	c, rec := newTestContext(http.MethodPost, "/", `{"message":"hi"}`, user.ID, "id", idParam(agent.ID))
+	if err := h.StreamChatWithAgent(c); err != nil {
+		t.Fatalf("StreamChatWithAgent: %v", err)
+	}
+	if ct := rec.Header().Get(echo.HeaderContentType); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	events := parseSSE(t, rec.Body.String())
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4: %+v", len(events), events)
+	}
+	for i, chunk := range []string{"Hel", "lo", " there"} {
+		var delta struct{ Content string }
+		json.Unmarshal([]byte(events[i].Data), &delta)
+		if events[i].Name != "delta" || delta.Content != chunk {
+			t.Errorf("event %d = %+v, want delta %q", i, events[i], chunk)
+		}
+	}
+
+	var done struct {
+		AgentID        uint   `json:"agentId"`
+		ConversationID uint   `json:"conversationId"`
+		Reply          string `json:"reply"`
+		Provider       string `json:"provider"`
+		Model          string `json:"model"`
+	}
+	if events[3].Name != "done" {
+		t.Fatalf("last event = %+v, want done", events[3])
+	}
+	json.Unmarshal([]byte(events[3].Data), &done)
+	if done.AgentID != agent.ID || done.Reply != "Hello there" || done.Provider != "fake" || done.Model != "fake" {
+		t.Errorf("done = %+v", done)
+	}
+
+	var stored []models.Message
+	h.DB.Where("conversation_id = ?", done.ConversationID).Order("id").Find(&stored)
+	if len(stored) != 2 || stored[1].Role != llm.RoleAssistant || stored[1].Content != "Hello there" {
+		t.Errorf("stored messages = %+v", stored)
+	}
+}
+
+func TestStreamChatWithAgentReportsProviderError(t *testing.T) {
+	h := newTestHandler(t, &llm.FakeProvider{Chunks: []string{"partial"}, Err: errors.New("upstream exploded")})
+	user := createTestUser(t, h.DB, "dave")
+	agent := createTestAgent(t, h.DB, models.Agent{Name: "Flaky", SystemPrompt: "Try.", UserID: user.ID})
+
+	c, rec := newTestContext(http.MethodPost, "/", `{"message":"hi"}`, user.ID, "id", idParam(agent.ID))
+	c.Logger().SetOutput(io.Discard)
+	if err := h.StreamChatWithAgent(c); err != nil {
+		t.Fatalf("StreamChatWithAgent: %v", err)
+	}
+
+	events := parseSSE(t, rec.Body.String())
+	if len(events) != 2 || events[0].Name != "delta" || events[1].Name != "error" {
+		t.Fatalf("events = %+v, want a delta then an error", events)
+	}
+	if strings.Contains(events[1].Data, "upstream exploded") {
+		t.Errorf("error event leaks the provider error: %s", events[1].Data)
+	}
+
+	var count int64
+	h.DB.Model(&models.Message{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d messages saved for a failed turn", count)
+	}
+}
+
+func TestStreamChatWithAgentStopsWhenClientGoesAway(t *testing.T) {
+	chunks := make([]string, 100)
+	for i := range chunks {
+		chunks[i] = "word "
+	}
+	h := newTestHandler(t, &llm.FakeProvider{Chunks: chunks, Delay: 20 * time.Millisecond})
+	user := createTestUser(t, h.DB, "erin")
+	agent := createTestAgent(t, h.DB, models.Agent{Name: "Chatty", SystemPrompt: "Talk.", UserID: user.ID})
+
+	returned := make(chan error, 1)
+	e := echo.New()
+	e.Validator = utils.NewValidator()
+	e.POST("/agents/:id/chat/stream", func(c echo.Context) error {
+		c.Set("user", &jwt.Token{Valid: true, Claims: jwt.MapClaims{"user_id": float64(user.ID)}})
+		err := h.StreamChatWithAgent(c)
+		returned <- err
+		return err
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/agents/"+idParam(agent.ID)+"/chat/stream",
+		strings.NewReader(`{"message":"hi"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer res.Body.Close()
+
+	// Wait for the first delta, then hang up.
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	if err != nil || line != "event: delta\n" {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+	cancel()
+
+	select {
+	case err := <-returned:
+		if err != nil {
+			t.Errorf("StreamChatWithAgent: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler kept streaming after the client went away")
+	}
+
+	var count int64
+	h.DB.Model(&models.Message{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d messages saved for an abandoned turn", count)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/similar"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ========== FORK ==========
+
+// POST /api/agents/:id/fork
+//
+// Copies an agent the caller can see into their account. The copy links
+// back to the original through ForkedFromID and is public unless the
+// request says otherwise.
+func (h *Handler) ForkAgent(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+	if err := h.checkCanCreateAgents(userID); err != nil {
+		return err
+	}
+
+	original, err := h.findAgent(c, withTaxonomy)
+	if err != nil {
+		return err
+	}
+	if original.UserID != userID && !original.ForksAllowed() {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "The author has disabled forking for this agent"})
+	}
+
+	var req struct {
+		Name       string `json:"name" validate:"max=100"`
+		Visibility string `json:"visibility"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if req.Name == "" {
+		req.Name = original.Name
+	}
+
+	fork := models.Agent{
+		Name:          req.Name,
+		Description:   original.Description,
+		Avatar:        original.Avatar,
+		SystemPrompt:  original.SystemPrompt,
+		InputTemplate: original.InputTemplate,
+		Personality:   original.Personality,
+		UserID:        userID,
+		CategoryID:    original.CategoryID,
+		ForkedFromID:  &original.ID,
+	}
+	if err := setVisibility(&fork, req.Visibility); err != nil {
+		return err
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags").Create(&fork).Error; err != nil {
+			return err
+		}
+		tags := make([]string, len(original.Tags))
+		for i, t := range original.Tags {
+			tags[i] = t.Name
+		}
+		if err := setAgentTags(tx, &fork, tags); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Agent{}).Where("id = ?", original.ID).
+			UpdateColumn("fork_count", gorm.Expr("fork_count + 1")).Error; err != nil {
+			return err
+		}
+		return recordAgentVersion(tx, fork, userID, fmt.Sprintf("Forked from agent %d", original.ID))
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fork agent"})
+	}
+
+	similar.MarkDirty(fork.ID)
+	h.DB.Scopes(withTaxonomy).First(&fork, fork.ID)
+	showShareSlug(c, &fork)
+	return c.JSON(http.StatusCreated, fork)
+}
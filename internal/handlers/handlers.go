@@ -1,11 +1,19 @@
 package handlers
 
 import (
+	"ai-agent-hub/internal/auth"
+	"ai-agent-hub/internal/llm"
 	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/prompt"
+	"ai-agent-hub/internal/similar"
+	"ai-agent-hub/internal/storage"
 	"ai-agent-hub/internal/utils"
+	"fmt"
+	"log"
 	"net/http"
 	"os"
-	"time"
+	"strconv"
+	"sync"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
@@ -14,11 +22,66 @@ import (
 )
 
 type Handler struct {
-	DB *gorm.DB
+	DB  *gorm.DB
+	LLM llm.Provider
+	// LineAPIURL overrides the LINE Messaging API base URL, e.g. to point
+	// at a local stand-in server. Empty means the real API.
+	LineAPIURL string
+	// RequireVerifiedEmail blocks agent creation until the user has
+	// verified their email (REQUIRE_EMAIL_VERIFICATION=true).
+	RequireVerifiedEmail bool
+	// Storage holds uploaded files such as avatars.
+	Storage storage.Storage
 }
 
+// llmFromEnv builds the LLM provider once for every Handler. Handlers are
+// built while routes are registered, so a bad LLM_PROVIDER stops the server
+// at startup.
+var llmFromEnv = sync.OnceValues(llm.NewProviderFromEnv)
+
 func NewHandler(db *gorm.DB) *Handler {
-	return &Handler{DB: db}
+	provider, err := llmFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return &Handler{
+		DB:                   db,
+		LLM:                  provider,
+		LineAPIURL:           os.Getenv("LINE_API_BASE_URL"),
+		RequireVerifiedEmail: os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true",
+		Storage:              storage.NewStorageFromEnv(),
+	}
+}
+
+// currentUserID returns the authenticated user's ID from the JWT claims.
+func currentUserID(c echo.Context) (uint, bool) {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return 0, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, false
+	}
+	id, ok := claims["user_id"].(float64) // JWT stores numbers as float64
+	if !ok {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// currentSessionID returns the login session the request's token belongs to.
+func currentSessionID(c echo.Context) string {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return ""
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	sid, _ := claims["sid"].(string)
+	return sid
 }
 
 // ========== AUTH ==========
@@ -29,6 +92,7 @@ func (h *Handler) Register(c echo.Context) error {
 		Username string `json:"username" validate:"required,min=3,max=32"`
 		Email    string `json:"email" validate:"required,email"`
 		Password string `json:"password" validate:"required,min=6"`
+		Avatar   string `json:"avatar" validate:"omitempty,avatar"`
 	}
 
 	var req RegisterRequest
@@ -47,6 +111,9 @@ func (h *Handler) Register(c echo.Context) error {
 	if err := h.DB.Where("email = ?", req.Email).First(&existing).Error; err != gorm.ErrRecordNotFound {
 		return c.JSON(http.StatusConflict, echo.Map{"error": "User already exists"})
 	}
+	if err := h.DB.Where("username = ?", req.Username).First(&existing).Error; err != gorm.ErrRecordNotFound {
+		return c.JSON(http.StatusConflict, echo.Map{"error": "Username is already taken"})
+	}
 
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -59,9 +126,19 @@ func (h *Handler) Register(c echo.Context) error {
 		Username: req.Username,
 		Email:    req.Email,
 		Password: string(hashedPassword),
+		Avatar:   req.Avatar,
+	}
+	if user.Avatar == "" {
+		user.Avatar = identiconURL("user-" + user.Username)
 	}
 
-	if err := h.DB.Create(&user).Error; err != nil {
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return sendVerificationEmail(tx, user)
+	})
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to create user"})
 	}
 
@@ -93,166 +170,242 @@ func (h *Handler) Login(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Invalid email or password"})
 	}
 
-	// Create JWT
-	claims := jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(), // 3 days
+	if user.Disabled() {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "Account has been disabled"})
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	tokens, err := auth.CreateSession(h.DB, user)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Could not sign token"})
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{"token": signedToken})
+	return c.JSON(http.StatusOK, tokens)
 }
 
-// ========== AGENT CRUD ==========
+// POST /api/auth/refresh
+func (h *Handler) RefreshToken(c echo.Context) error {
+	type RefreshRequest struct {
+		RefreshToken string `json:"refreshToken" validate:"required"`
+	}
 
-// GET /api/agents
-func (h *Handler) GetAgents(c echo.Context) error {
+	var req RefreshRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
 
-	p := utils.GetPagination(c)
+	tokens, err := auth.Rotate(h.DB, req.RefreshToken)
+	if err != nil {
+		switch err {
+		case auth.ErrRefreshTokenReused:
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Refresh token reuse detected, session revoked"})
+		case auth.ErrInvalidRefreshToken:
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Invalid or expired refresh token"})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Could not refresh token"})
+	}
 
-	var agents []models.Agent
-	var total int64
+	return c.JSON(http.StatusOK, tokens)
+}
 
-	h.DB.Model(&models.Agent{}).Count(&total)
-	if err := h.DB.Limit(p.Limit + 1).Offset(p.Offset).Find(&agents).Error; err != nil {
-		return err
+// POST /api/auth/logout
+func (h *Handler) Logout(c echo.Context) error {
+	type LogoutRequest struct {
+		RefreshToken string `json:"refreshToken" validate:"required"`
 	}
 
-	hasMore := len(agents) > p.Limit
-	if hasMore {
-		agents = agents[:p.Limit]
+	var req LogoutRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
 	}
 
-	resp := utils.NewPaginatedResponse(agents, p.Page, p.Limit, hasMore, total)
-	return c.JSON(http.StatusOK, resp)
+	if err := auth.RevokeByRefreshToken(h.DB, req.RefreshToken); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Could not log out"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
 }
 
-// GET /api/agents/:id
-func (h *Handler) GetAgentsByID(c echo.Context) error {
-	id := c.Param("id")
+// ========== AGENT CRUD ==========
 
-	var agent models.Agent
-	if err := h.DB.First(&agent, "id = ?", id).Error; err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, "Agent not found")
+// agentListSpec is what the public and owner agent lists can be sorted and
+// filtered by. ?category= and ?tag= are handled by taxonomyFilter.
+func agentListSpec(defaultSort string) utils.ListSpec {
+	return utils.ListSpec{
+		Sorts: map[string]string{
+			"created_at":     "agents.created_at",
+			"updated_at":     "agents.updated_at",
+			"name":           "agents.name",
+			"view_count":     "agents.view_count",
+			"like_count":     "agents.like_count",
+			"fork_count":     "agents.fork_count",
+			"rating_average": "agents.rating_average",
+			"rating_count":   "agents.rating_count",
+		},
+		DefaultSort: defaultSort,
+		Filters: map[string]utils.Filter{
+			"user_id":        {Column: "agents.user_id", Kind: utils.FilterNumber},
+			"forked_from_id": {Column: "agents.forked_from_id", Kind: utils.FilterNumber},
+			"is_featured":    {Column: "agents.is_featured", Kind: utils.FilterBool},
+			"visibility":     {Column: "agents.visibility", Kind: utils.FilterEquals},
+			"name":           {Column: "agents.name", Kind: utils.FilterContains},
+		},
+		DateRanges: map[string]string{
+			"created_at": "agents.created_at",
+			"updated_at": "agents.updated_at",
+		},
+		Key:   "agents.id",
+		Model: &models.Agent{},
 	}
-
-	return c.JSON(http.StatusOK, agent)
 }
 
-// GET /api/user/:user_id/agents
-func (h *Handler) GetAgentsOfUserID(c echo.Context) error {
-	p := utils.GetPagination(c)
-	userID := c.Param("user_id")
+// listAgents serves a page of agents matching base plus the request's
+// filters and sort, as allowed by spec.
+func (h *Handler) listAgents(c echo.Context, spec utils.ListSpec, base func(*gorm.DB) *gorm.DB) error {
+	lq, err := utils.ParseListQuery(c, spec)
+	if err != nil {
+		return err
+	}
+	taxonomy, err := taxonomyFilter(c)
+	if err != nil {
+		return err
+	}
 
-	var agents []models.Agent
-	var total int64
+	scope := func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(base, lq.Where, taxonomy)
+	}
 
-	h.DB.Model(&models.Agent{}).Count(&total)
-	if err := h.DB.Where("user_id = ?", userID).Find(&agents).Error; err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch agents for user")
+	var total int64
+	if err := h.DB.Model(&models.Agent{}).Scopes(scope).Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch agents"})
 	}
 
-	hasMore := len(agents) > p.Limit
-	if hasMore {
-		agents = agents[:p.Limit]
+	var agents []models.Agent
+	if err := h.DB.Scopes(withTaxonomy, scope, lq.Paginate).Find(&agents).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch agents"})
 	}
 
-	resp := utils.NewPaginatedResponse(agents, p.Page, p.Limit, hasMore, total)
+	agents, page := utils.FinishPage(lq, agents)
+
+	h.markLiked(c, agents)
+	showShareSlugs(c, agents)
+	resp := lq.Response(agents, page, total)
+	utils.SetLinkHeader(c, resp)
 	return c.JSON(http.StatusOK, resp)
 }
 
-// GET /api/agents/featured
-func (h *Handler) GetFeaturedAgents(c echo.Context) error {
-	p := utils.GetPagination(c)
-
-	var agents []models.Agent
-	var total int64
+// GET /api/agents
+func (h *Handler) GetAgents(c echo.Context) error {
+	return h.listAgents(c, agentListSpec("-created_at"), publicAgents)
+}
 
-	h.DB.Model(&models.Agent{}).Count(&total)
-	if err := h.DB.Where("is_featured = ?", true).Limit(p.Limit + 1).Offset(p.Offset).Find(&agents).Error; err != nil {
+// GET /api/agents/:id
+//
+// :id may also be the share slug of an unlisted agent, see findAgent.
+func (h *Handler) GetAgentsByID(c echo.Context) error {
+	agent, err := h.findAgent(c, withTaxonomy)
+	if err != nil {
 		return err
 	}
 
-	hasMore := len(agents) > p.Limit
-	if hasMore {
-		agents = agents[:p.Limit]
+	h.recordView(c, &agent)
+	h.markLikedOne(c, &agent)
+	showShareSlug(c, &agent)
+	return c.JSON(http.StatusOK, agent)
+}
+
+// GET /api/user/:user_id/agents
+func (h *Handler) GetAgentsOfUserID(c echo.Context) error {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
 	}
 
-	resp := utils.NewPaginatedResponse(agents, p.Page, p.Limit, hasMore, total)
-	return c.JSON(http.StatusOK, resp)
+	return h.listAgents(c, agentListSpec("-created_at"), func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(publicAgents).Where("agents.user_id = ?", userID)
+	})
+}
+
+// GET /api/agents/featured
+func (h *Handler) GetFeaturedAgents(c echo.Context) error {
+	return h.listAgents(c, agentListSpec("-created_at"), func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(publicAgents).Where("agents.is_featured = ?", true)
+	})
 }
 
 // GET /api/agents/popular
 func (h *Handler) GetPopularAgents(c echo.Context) error {
-	p := utils.GetPagination(c)
-
-	var agents []models.Agent
-	var total int64
-
-	h.DB.Model(&models.Agent{}).Count(&total)
-	if err := h.DB.Order("view_count desc").Limit(p.Limit + 1).Offset(p.Offset).Find(&agents).Error; err != nil {
-		return err
-	}
-
-	hasMore := len(agents) > p.Limit
-	if hasMore {
-		agents = agents[:p.Limit]
-	}
+	return h.listAgents(c, agentListSpec("-view_count"), publicAgents)
+}
 
-	resp := utils.NewPaginatedResponse(agents, p.Page, p.Limit, hasMore, total)
-	return c.JSON(http.StatusOK, resp)
+// GET /api/agents/trending
+//
+// Ranked by the time-decayed score the trending job keeps in
+// agent_trending_scores; agents with no recent activity are not listed.
+func (h *Handler) GetTrendingAgents(c echo.Context) error {
+	spec := agentListSpec("-trending_score")
+	spec.Sorts["trending_score"] = "agent_trending_scores.score"
+
+	return h.listAgents(c, spec, func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(publicAgents).Joins("JOIN agent_trending_scores ON agent_trending_scores.agent_id = agents.id")
+	})
 }
 
 // ===============================================================================================================
 // GET /api/my/agents
+//
+// All of the caller's agents, whatever their visibility.
 func (h *Handler) GetMyAgents(c echo.Context) error {
-	p := utils.GetPagination(c)
-
-	user := c.Get("user").(*jwt.Token)
-	claims := user.Claims.(jwt.MapClaims)
-	userID := claims["user_id"].(string)
-
-	var agents []models.Agent
-	var total int64
-
-	h.DB.Model(&models.Agent{}).Count(&total)
-	if err := h.DB.Where("user_id = ?", userID).Find(&agents).Error; err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch your agents")
-	}
-
-	hasMore := len(agents) > p.Limit
-	if hasMore {
-		agents = agents[:p.Limit]
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
 	}
 
-	resp := utils.NewPaginatedResponse(agents, p.Page, p.Limit, hasMore, total)
-	return c.JSON(http.StatusOK, resp)
+	return h.listAgents(c, agentListSpec("-updated_at"), func(db *gorm.DB) *gorm.DB {
+		return db.Where("agents.user_id = ?", userID)
+	})
 }
 
 // GET /api/my/agents/:id
 func (h *Handler) GetMyAgentByID(c echo.Context) error {
-	user := c.Get("user").(*jwt.Token)
-	claims := user.Claims.(jwt.MapClaims)
-	userID := claims["user_id"].(string)
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
 	agentID := c.Param("id")
 
 	var agent models.Agent
-	if err := h.DB.Where("id = ? AND user_id = ?", agentID, userID).First(&agent).Error; err != nil {
+	if err := h.DB.Scopes(withTaxonomy).Where("id = ? AND user_id = ?", agentID, userID).First(&agent).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.JSON(http.StatusNotFound, echo.Map{"error": "Agent not found"})
 		}
 		return err
 	}
 
+	h.markLikedOne(c, &agent)
+	showShareSlug(c, &agent)
 	return c.JSON(http.StatusOK, agent)
 }
 
+// AgentInput is the request body for creating and updating agents. The
+// category is given by slug and tags by name; omitting either, or the
+// visibility, leaves it unchanged on update.
+type AgentInput struct {
+	models.Agent
+	Category *string  `json:"category"`
+	Tags     []string `json:"tags"`
+}
+
+// editableAgentColumns are the columns an owner's edit writes. Counters,
+// featuring and ownership are changed atomically elsewhere, so they are never
+// written back from a row loaded earlier.
+var editableAgentColumns = append([]string{"allow_forks", "visibility", "share_slug", "category_id"}, versionedAgentColumns...)
+
 // POST /api/my/agents
 func (h *Handler) CreateMyAgents(c echo.Context) error {
 	user := c.Get("user")
@@ -274,11 +427,27 @@ func (h *Handler) CreateMyAgents(c echo.Context) error {
 	// claims := user.Claims.(jwt.MapClaims)
 	userID := uint(claims["user_id"].(float64)) // JWT stores numbers as float64
 
-	var input models.Agent
+	if err := h.checkCanCreateAgents(userID); err != nil {
+		return err
+	}
+
+	var input AgentInput
 	if err := c.Bind(&input); err != nil {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
 	}
 
+	if err := prompt.Validate(input.InputTemplate); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid input template: " + err.Error()})
+	}
+	if input.Avatar != "" && !utils.ValidAvatarURL(input.Avatar) {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Avatar must be an http or https URL"})
+	}
+
+	tags, err := normalizeTags(input.Tags)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
 	agent := models.Agent{
 		Name:          input.Name,
 		Description:   input.Description,
@@ -287,15 +456,65 @@ func (h *Handler) CreateMyAgents(c echo.Context) error {
 		InputTemplate: input.InputTemplate,
 		Personality:   input.Personality,
 		UserID:        userID,
+		AllowForks:    input.AllowForks,
+	}
+	if err := setVisibility(&agent, input.Visibility); err != nil {
+		return err
+	}
+	if input.Category != nil {
+		if agent.CategoryID, err = resolveCategory(h.DB, *input.Category); err != nil {
+			return err
+		}
 	}
 
-	if err := h.DB.Create(&agent).Error; err != nil {
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		return createAgent(tx, &agent, tags, "Created")
+	})
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to create agent"})
 	}
 
+	similar.MarkDirty(agent.ID)
+	h.DB.Scopes(withTaxonomy).First(&agent, agent.ID)
+	showShareSlug(c, &agent)
 	return c.JSON(http.StatusCreated, agent)
 }
 
+// createAgent inserts agent with its tags and records its first version.
+// Agents without an avatar get an identicon keyed on their ID.
+func createAgent(tx *gorm.DB, agent *models.Agent, tags []string, note string) error {
+	if err := tx.Create(agent).Error; err != nil {
+		return err
+	}
+	if agent.Avatar == "" {
+		agent.Avatar = identiconURL(fmt.Sprintf("agent-%d", agent.ID))
+		if err := tx.Model(agent).Update("avatar", agent.Avatar).Error; err != nil {
+			return err
+		}
+	}
+	if err := setAgentTags(tx, agent, tags); err != nil {
+		return err
+	}
+	return recordAgentVersion(tx, *agent, agent.UserID, note)
+}
+
+// checkCanCreateAgents enforces REQUIRE_EMAIL_VERIFICATION for anything
+// that creates an agent.
+func (h *Handler) checkCanCreateAgents(userID uint) error {
+	if !h.RequireVerifiedEmail {
+		return nil
+	}
+
+	var owner models.User
+	if err := h.DB.First(&owner, userID).Error; err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "User not found")
+	}
+	if !owner.EmailVerified() {
+		return echo.NewHTTPError(http.StatusForbidden, "Please verify your email before creating agents")
+	}
+	return nil
+}
+
 // PUT /api/my/agents/:id
 func (h *Handler) UpdateMyAgent(c echo.Context) error {
 	user := c.Get("user").(*jwt.Token)
@@ -308,127 +527,87 @@ func (h *Handler) UpdateMyAgent(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, echo.Map{"error": "Agent not found"})
 	}
 
-	var input models.Agent
+	var input AgentInput
 	if err := c.Bind(&input); err != nil {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request body"})
 	}
 
+	if err := prompt.Validate(input.InputTemplate); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid input template: " + err.Error()})
+	}
+	if input.Avatar != "" && !utils.ValidAvatarURL(input.Avatar) {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Avatar must be an http or https URL"})
+	}
+
+	tags, err := normalizeTags(input.Tags)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	agentBefore := agent
 	agent.Name = input.Name
 	agent.Description = input.Description
 	agent.Avatar = input.Avatar
 	agent.SystemPrompt = input.SystemPrompt
 	agent.InputTemplate = input.InputTemplate
 	agent.Personality = input.Personality
+	if input.AllowForks != nil {
+		agent.AllowForks = input.AllowForks
+	}
+	if err := setVisibility(&agent, input.Visibility); err != nil {
+		return err
+	}
+	if input.Category != nil {
+		if agent.CategoryID, err = resolveCategory(h.DB, *input.Category); err != nil {
+			return err
+		}
+	}
 
-	if err := h.DB.Save(&agent).Error; err != nil {
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureInitialVersion(tx, agentBefore); err != nil {
+			return err
+		}
+		if err := tx.Model(&agent).Select(editableAgentColumns).Updates(&agent).Error; err != nil {
+			return err
+		}
+		if input.Tags != nil {
+			if err := setAgentTags(tx, &agent, tags); err != nil {
+				return err
+			}
+		}
+		return recordAgentVersion(tx, agent, userID, "")
+	})
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to update agent"})
 	}
 
+	similar.MarkDirty(agent.ID)
+	h.DB.Scopes(withTaxonomy).First(&agent, agent.ID)
+	showShareSlug(c, &agent)
 	return c.JSON(http.StatusOK, agent)
 }
 
 // DELETE /api/my/agents/:id
 func (h *Handler) DeleteMyAgent(c echo.Context) error {
-	user := c.Get("user").(*jwt.Token)
-	claims := user.Claims.(jwt.MapClaims)
-	userID := claims["user_id"].(string)
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
 	agentID := c.Param("id")
 
-	if err := h.DB.Where("id = ? AND user_id = ?", agentID, userID).Delete(&models.Agent{}).Error; err != nil {
-		return err
+	result := h.DB.Where("id = ? AND user_id = ?", agentID, userID).Delete(&models.Agent{})
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to delete agent"})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "Agent not found"})
 	}
 
+	id, _ := strconv.ParseUint(agentID, 10, 64)
+	similar.MarkDirty(uint(id))
 	return c.NoContent(http.StatusNoContent)
 }
 
-// // ========== CHAT ==========
-
-// func (h *Handler) ChatWithAgent(c echo.Context) error {
-// 	id := c.Param("id")
-// 	var agent models.Agent
-// 	if err := h.DB.First(&agent, id).Error; err != nil {
-// 		return echo.ErrNotFound
-// 	}
-
-// 	var req struct {
-// 		Message string `json:"message"`
-// 	}
-// 	if err := c.Bind(&req); err != nil {
-// 		return err
-// 	}
-
-// 	// Simulate response (you'll connect to Python microservice here)
-// 	response := "This is a simulated reply from agent: " + agent.Name
-// 	return c.JSON(http.StatusOK, echo.Map{
-// 		"agent_id": agent.ID,
-// 		"message":  req.Message,
-// 		"reply":    response,
-// 	})
-// }
-
-// // ========== PROFILE ==========
-
-// func (h *Handler) GetProfile(c echo.Context) error {
-// 	userID := c.Get("user").(int)
-// 	var user models.User
-// 	if err := h.DB.First(&user, userID).Error; err != nil {
-// 		return echo.ErrUnauthorized
-// 	}
-// 	user.Password = "" // don't return hashed password
-// 	return c.JSON(http.StatusOK, user)
-// }
-
-// func (h *Handler) UpdateProfile(c echo.Context) error {
-// 	userID := c.Get("user").(int)
-// 	var user models.User
-// 	if err := h.DB.First(&user, userID).Error; err != nil {
-// 		return echo.ErrUnauthorized
-// 	}
-
-// 	var req models.User
-// 	if err := c.Bind(&req); err != nil {
-// 		return err
-// 	}
-// 	user.Username = req.Username
-// 	if req.Password != "" {
-// 		user.Password, _ = utils.HashPassword(req.Password)
-// 	}
-
-// 	if err := h.DB.Save(&user).Error; err != nil {
-// 		return err
-// 	}
-// 	user.Password = ""
-// 	return c.JSON(http.StatusOK, user)
-// }
-
-// // ========== LINE LINK ==========
-
-// func (h *Handler) LinkAgentToLine(c echo.Context) error {
-// 	id := c.Param("id")
-// 	userID := c.Get("user").(int)
-
-// 	var agent models.Agent
-// 	if err := h.DB.First(&agent, id).Error; err != nil {
-// 		return echo.ErrNotFound
-// 	}
-// 	if agent.UserID != uint(userID) {
-// 		return echo.ErrUnauthorized
-// 	}
-
-// 	var req struct {
-// 		WebhookURL string `json:"webhook_url"`
-// 	}
-// 	if err := c.Bind(&req); err != nil {
-// 		return err
-// 	}
-// 	agent.LineWebhook = req.WebhookURL
-// 	if err := h.DB.Save(&agent).Error; err != nil {
-// 		return err
-// 	}
-
-// 	return c.JSON(http.StatusOK, echo.Map{"message": "Agent linked to LINE", "webhook": agent.LineWebhook})
-// }
-
 //==========================================================
 
 // package handlers
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/utils"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestHandler returns a Handler backed by a throwaway SQLite database.
+// Only handlers whose queries are portable can be tested this way; search,
+// analytics roll-ups and trending rely on Postgres.
+func newTestHandler(t *testing.T, provider llm.Provider) *Handler {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Category{},
+		&models.Tag{},
+		&models.Agent{},
+		&models.Conversation{},
+		&models.Message{},
+		&models.AgentEvent{},
+		&models.AgentVersion{},
+		&models.AgentLike{},
+		&models.UserToken{},
+		&models.OutboxEmail{},
+		&models.Review{},
+		&models.RefreshToken{},
+	); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	return &Handler{DB: db, LLM: provider}
+}
+
+// createTestUser inserts a user named name.
+func createTestUser(t *testing.T, db *gorm.DB, name string) models.User {
+	t.Helper()
+	user := models.User{Username: name, Email: name + "@example.com", Password: "x"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// createTestAgent inserts agent, public unless it says otherwise.
+func createTestAgent(t *testing.T, db *gorm.DB, agent models.Agent) models.Agent {
+	t.Helper()
+	if agent.Visibility == "" {
+		agent.Visibility = models.VisibilityPublic
+	}
+	if err := db.Create(&agent).Error; err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	return agent
+}
+
+// newTestContext builds a request context for calling a handler directly.
+// userID, when non-zero, is authenticated the way the JWT middleware
+// would; params are route parameter name/value pairs.
+func newTestContext(method, target, body string, userID uint, params ...string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = utils.NewValidator()
+
+	// A context only has room for as many params as the longest route.
+	var names, values []string
+	for i := 0; i+1 < len(params); i += 2 {
+		names = append(names, params[i])
+		values = append(values, params[i+1])
+	}
+	route := ""
+	for _, name := range names {
+		route += "/:" + name
+	}
+	e.Any(route, func(echo.Context) error { return nil })
+
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, r)
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	c.SetParamNames(names...)
+	c.SetParamValues(values...)
+	if userID != 0 {
+		c.Set("user", &jwt.Token{Valid: true, Claims: jwt.MapClaims{"user_id": float64(userID)}})
+	}
+	return c, rec
+}
+
+func idParam(id uint) string {
+	return fmt.Sprint(id)
+}
+
+// afterAgentLoad runs fn once, right after the next query that reads
+// agents. It lets a test slip a concurrent write in between a handler
+// loading an agent and writing it back.
+func afterAgentLoad(t *testing.T, db *gorm.DB, fn func()) {
+	t.Helper()
+	fired := false
+	err := db.Callback().Query().After("gorm:query").Register("test:after_agent_load", func(tx *gorm.DB) {
+		if fired || tx.Statement.Table != "agents" {
+			return
+		}
+		fired = true
+		fn()
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+}
+
+// likeTestAgent likes agentID as userID through LikeAgent.
+func likeTestAgent(t *testing.T, h *Handler, agentID, userID uint) {
+	t.Helper()
+	c, rec := newTestContext(http.MethodPut, "/", "", userID, "id", idParam(agentID))
+	if err := h.LikeAgent(c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("LikeAgent: %v, status %d", err, rec.Code)
+	}
+}
+
+func TestUpdateMyAgentKeepsConcurrentCounters(t *testing.T) {
+	h := newTestHandler(t, &llm.EchoProvider{})
+	owner := createTestUser(t, h.DB, "owner")
+	fan := createTestUser(t, h.DB, "fan")
+	agent := createTestAgent(t, h.DB, models.Agent{Name: "Before", UserID: owner.ID})
+
+	// A fan likes the agent and an admin features it while the owner's
+	// edit is in flight.
+	afterAgentLoad(t, h.DB, func() {
+		likeTestAgent(t, h, agent.ID, fan.ID)
+		h.DB.Model(&models.Agent{}).Where("id = ?", agent.ID).UpdateColumn("is_featured", true)
+	})
+
+	c, rec := newTestContext(http.MethodPut, "/", `{"name":"After","system_prompt":"Be brief."}`, owner.ID, "id", idParam(agent.ID))
+	if err := h.UpdateMyAgent(c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("UpdateMyAgent: %v, status %d, body %s", err, rec.Code, rec.Body)
+	}
+
+	var after models.Agent
+	h.DB.First(&after, agent.ID)
+	if after.Name != "After" || after.SystemPrompt != "Be brief." {
+		t.Errorf("edit not saved: name %q, system prompt %q", after.Name, after.SystemPrompt)
+	}
+	if after.LikeCount != 1 || !after.IsFeatured {
+		t.Errorf("likeCount = %d, isFeatured = %v; the edit overwrote them", after.LikeCount, after.IsFeatured)
+	}
+	if after.Visibility != models.VisibilityPublic {
+		t.Errorf("visibility = %q, want it unchanged", after.Visibility)
+	}
+}
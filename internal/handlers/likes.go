@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/utils"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========== LIKES ==========
+
+// markLiked sets LikedByMe on agents the current user has liked. Anonymous
+// requests are left untouched.
+func (h *Handler) markLiked(c echo.Context, agents []models.Agent) {
+	userID, ok := currentUserID(c)
+	if !ok || len(agents) == 0 {
+		return
+	}
+
+	ids := make([]uint, len(agents))
+	for i, a := range agents {
+		ids[i] = a.ID
+	}
+
+	var liked []uint
+	if err := h.DB.Model(&models.AgentLike{}).Where("user_id = ? AND agent_id IN ?", userID, ids).
+		Pluck("agent_id", &liked).Error; err != nil {
+		c.Logger().Errorf("load likes: %v", err)
+		return
+	}
+
+	set := make(map[uint]bool, len(liked))
+	for _, id := range liked {
+		set[id] = true
+	}
+	for i := range agents {
+		agents[i].LikedByMe = set[agents[i].ID]
+	}
+}
+
+func (h *Handler) markLikedOne(c echo.Context, agent *models.Agent) {
+	agents := []models.Agent{*agent}
+	h.markLiked(c, agents)
+	agent.LikedByMe = agents[0].LikedByMe
+}
+
+// PUT /api/agents/:id/like
+func (h *Handler) LikeAgent(c echo.Context) error {
+	return h.setLiked(c, true)
+}
+
+// DELETE /api/agents/:id/like
+func (h *Handler) UnlikeAgent(c echo.Context) error {
+	return h.setLiked(c, false)
+}
+
+// setLiked likes or unlikes an agent. Both directions are idempotent and
+// only move LikeCount when the relation actually changes.
+func (h *Handler) setLiked(c echo.Context, like bool) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	agent, err := h.findAgent(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		var res *gorm.DB
+		delta := "like_count + 1"
+		if like {
+			res = tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.AgentLike{UserID: userID, AgentID: agent.ID})
+		} else {
+			res = tx.Where("user_id = ? AND agent_id = ?", userID, agent.ID).Delete(&models.AgentLike{})
+			delta = "GREATEST(like_count - 1, 0)"
+		}
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		if err := tx.Model(&models.Agent{}).Where("id = ?", agent.ID).
+			UpdateColumn("like_count", gorm.Expr(delta)).Error; err != nil {
+			return err
+		}
+		return tx.Select("like_count").First(&agent, agent.ID).Error
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to update like"})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"agentId": agent.ID, "liked": like, "likeCount": agent.LikeCount})
+}
+
+// GET /api/my/favorites
+func (h *Handler) GetMyFavorites(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+	spec := agentListSpec("-liked_at")
+	spec.Sorts["liked_at"] = "agent_likes.created_at"
+	spec.DateRanges["liked_at"] = "agent_likes.created_at"
+	lq, err := utils.ParseListQuery(c, spec)
+	if err != nil {
+		return err
+	}
+	taxonomy, err := taxonomyFilter(c)
+	if err != nil {
+		return err
+	}
+
+	// Only agents the caller can still discover are listed. An unlisted
+	// agent may have been liked while public, before its share link existed.
+	scope := func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN agent_likes ON agent_likes.agent_id = agents.id").
+			Where("agent_likes.user_id = ?", userID).
+			Where("(agents.visibility = ? OR agents.user_id = ?)", models.VisibilityPublic, userID).
+			Scopes(lq.Where, taxonomy)
+	}
+
+	var total int64
+	if err := h.DB.Model(&models.Agent{}).Scopes(scope).Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch favorites"})
+	}
+
+	var agents []models.Agent
+	if err := h.DB.Scopes(withTaxonomy, scope, lq.Paginate).Find(&agents).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch favorites"})
+	}
+
+	agents, page := utils.FinishPage(lq, agents)
+	for i := range agents {
+		agents[i].LikedByMe = true
+	}
+	showShareSlugs(c, agents)
+
+	resp := lq.Response(agents, page, total)
+	utils.SetLinkHeader(c, resp)
+	return c.JSON(http.StatusOK, resp)
+}
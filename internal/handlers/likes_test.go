@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/models"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFavoritesHideAgentsUnlistedAfterLiking(t *testing.T) {
+	h := newTestHandler(t, &llm.EchoProvider{})
+	owner := createTestUser(t, h.DB, "owner")
+	fan := createTestUser(t, h.DB, "fan")
+
+	public := createTestAgent(t, h.DB, models.Agent{Name: "Still public", UserID: owner.ID})
+	hidden := createTestAgent(t, h.DB, models.Agent{Name: "Now unlisted", UserID: owner.ID})
+	for _, a := range []models.Agent{public, hidden} {
+		if err := h.DB.Create(&models.AgentLike{UserID: fan.ID, AgentID: a.ID}).Error; err != nil {
+			t.Fatalf("like agent: %v", err)
+		}
+	}
+
+	// The owner makes the liked agent unlisted, which creates its share link.
+	if err := setVisibility(&hidden, models.VisibilityUnlisted); err != nil {
+		t.Fatalf("setVisibility: %v", err)
+	}
+	if err := h.DB.Save(&hidden).Error; err != nil {
+		t.Fatalf("save agent: %v", err)
+	}
+	slug := *hidden.ShareSlug
+
+	c, rec := newTestContext(http.MethodGet, "/", "", fan.ID)
+	if err := h.GetMyFavorites(c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GetMyFavorites: %v, status %d", err, rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "Still public") {
+		t.Errorf("public favorite missing: %s", body)
+	}
+	if strings.Contains(body, "Now unlisted") || strings.Contains(body, slug) {
+		t.Errorf("favorites expose the unlisted agent: %s", body)
+	}
+
+	// The owner still sees the link.
+	c, rec = newTestContext(http.MethodGet, "/", "", owner.ID, "id", idParam(hidden.ID))
+	if err := h.GetMyAgentByID(c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GetMyAgentByID: %v, status %d", err, rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"shareSlug":"`+slug+`"`) {
+		t.Errorf("owner response lacks the share slug: %s", rec.Body)
+	}
+
+	// Anyone holding the link can open the agent, but is not shown the slug.
+	c, rec = newTestContext(http.MethodGet, "/", "", fan.ID, "id", slug)
+	if err := h.GetAgentsByID(c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GetAgentsByID: %v, status %d", err, rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "shareSlug") {
+		t.Errorf("non-owner response includes the share slug: %s", rec.Body)
+	}
+}
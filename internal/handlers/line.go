@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/line"
+	"ai-agent-hub/internal/models"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ========== LINE ==========
+
+// maxWebhookBody bounds how much of a webhook request is read.
+const maxWebhookBody = 1 << 20
+
+// lineWebhookURL is the URL an agent's LINE channel should call.
+func lineWebhookURL(agentID uint) string {
+	base := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	return fmt.Sprintf("%s/api/line/webhook/%d", base, agentID)
+}
+
+// POST /api/my/agents/:id/line
+func (h *Handler) LinkAgentToLine(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	var agent models.Agent
+	if err := h.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&agent).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "Agent not found"})
+	}
+
+	var req struct {
+		ChannelSecret      string `json:"channelSecret" validate:"required"`
+		ChannelAccessToken string `json:"channelAccessToken" validate:"required"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	webhookURL := lineWebhookURL(agent.ID)
+	if err := h.DB.Model(&agent).Updates(map[string]any{
+		"line_channel_secret":       req.ChannelSecret,
+		"line_channel_access_token": req.ChannelAccessToken,
+		"line_webhook":              webhookURL,
+	}).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to link agent"})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "Agent linked to LINE", "webhook": webhookURL})
+}
+
+// DELETE /api/my/agents/:id/line
+func (h *Handler) UnlinkAgentFromLine(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	result := h.DB.Model(&models.Agent{}).Where("id = ? AND user_id = ?", c.Param("id"), userID).Updates(map[string]any{
+		"line_channel_secret":       "",
+		"line_channel_access_token": "",
+		"line_webhook":              "",
+	})
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to unlink agent"})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "Agent not found"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// POST /api/line/webhook/:agent_id
+//
+// Receives events from LINE. Requests are authenticated by the
+// X-Line-Signature HMAC, keyed with the agent's channel secret. Events are
+// answered asynchronously after the 200.
+func (h *Handler) LineWebhook(c echo.Context) error {
+	var agent models.Agent
+	if err := h.DB.First(&agent, "id = ?", c.Param("agent_id")).Error; err != nil || agent.LineChannelSecret == "" {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "Agent not found"})
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxWebhookBody))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if !line.ValidateSignature(agent.LineChannelSecret, body, c.Request().Header.Get(line.SignatureHeader)) {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Invalid signature"})
+	}
+
+	webhook, err := line.ParseWebhook(body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+
+	// Answering takes an LLM call per event, which can outlast LINE's
+	// webhook timeout and make it redeliver the batch, so events are
+	// acknowledged now and answered in the background.
+	for _, event := range webhook.Events {
+		if !event.IsTextMessage() {
+			continue
+		}
+		if !enqueueLineEvent(lineJob{h: h, agent: agent, event: event}) {
+			c.Logger().Errorf("line webhook for agent %d: queue full, event dropped", agent.ID)
+		}
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+const (
+	lineWorkers   = 8
+	lineQueueSize = 64
+	// lineEventTimeout bounds answering one event. LINE reply tokens expire
+	// shortly after the event anyway.
+	lineEventTimeout = time.Minute
+)
+
+// lineJob is one webhook event waiting to be answered.
+type lineJob struct {
+	h     *Handler
+	agent models.Agent
+	event line.Event
+}
+
+var (
+	lineQueuesOnce sync.Once
+	lineQueues     []chan lineJob
+)
+
+// enqueueLineEvent hands an event to the LINE workers, reporting false when
+// its queue is full. Events of one LINE user always go to the same worker,
+// so they are answered in order and each sees the previous turn.
+func enqueueLineEvent(job lineJob) bool {
+	lineQueuesOnce.Do(func() {
+		for i := 0; i < lineWorkers; i++ {
+			q := make(chan lineJob, lineQueueSize)
+			lineQueues = append(lineQueues, q)
+			go runLineWorker(q)
+		}
+	})
+
+	f := fnv.New32a()
+	fmt.Fprintf(f, "%d/%s", job.agent.ID, job.event.Source.UserID)
+	select {
+	case lineQueues[f.Sum32()%lineWorkers] <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+func runLineWorker(q <-chan lineJob) {
+	for job := range q {
+		ctx, cancel := context.WithTimeout(context.Background(), lineEventTimeout)
+		client := line.NewClient(job.h.LineAPIURL, job.agent.LineChannelAccessToken)
+		if err := job.h.answerLineEvent(ctx, client, job.agent, job.event); err != nil {
+			// LINE retries are not useful for a failed reply; log and move on.
+			log.Printf("line webhook for agent %d: %v", job.agent.ID, err)
+		}
+		cancel()
+	}
+}
+
+// answerLineEvent runs one chat turn for a LINE text message and replies to it.
+func (h *Handler) answerLineEvent(ctx context.Context, client *line.Client, agent models.Agent, event line.Event) error {
+	s := &chatSession{Agent: agent, Message: event.Message.Text}
+
+	err := h.DB.Where("agent_id = ? AND line_user_id = ?", agent.ID, event.Source.UserID).
+		First(&s.Conversation).Error
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		s.Conversation = models.Conversation{
+			AgentID:    agent.ID,
+			LineUserID: event.Source.UserID,
+			Title:      conversationTitle(event.Message.Text),
+		}
+	case err != nil:
+		return err
+	default:
+		if s.History, err = h.loadHistory(s.Conversation.ID); err != nil {
+			return err
+		}
+	}
+
+	if s.Input, err = renderInput(agent.InputTemplate, s.Message, nil); err != nil {
+		return err
+	}
+
+	resp, err := h.LLM.Complete(ctx, buildChatRequest(agent, s.History, s.Input))
+	if err != nil {
+		return err
+	}
+	if err := h.saveTurn(s, resp.Content); err != nil {
+		return err
+	}
+
+	return client.ReplyText(ctx, event.ReplyToken, resp.Content)
+}
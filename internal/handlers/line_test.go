@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/line"
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lineReply is one call received by the stand-in LINE server.
+type lineReply struct {
+	Path          string
+	Authorization string
+	ReplyToken    string `json:"replyToken"`
+	Messages      []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"messages"`
+}
+
+// newLineServer starts a stand-in for the LINE Messaging API that records
+// every reply it is sent.
+func newLineServer(t *testing.T) (*httptest.Server, func() []lineReply) {
+	t.Helper()
+	var mu sync.Mutex
+	var replies []lineReply
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reply lineReply
+		if err := json.NewDecoder(r.Body).Decode(&reply); err != nil {
+			t.Errorf("decode reply: %v", err)
+		}
+		reply.Path = r.URL.Path
+		reply.Authorization = r.Header.Get("Authorization")
+		mu.Lock()
+		replies = append(replies, reply)
+		mu.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []lineReply {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]lineReply(nil), replies...)
+	}
+}
+
+// waitForReplies waits until the stand-in LINE server has n replies, since
+// webhook events are answered in the background.
+func waitForReplies(t *testing.T, replies func() []lineReply, n int) []lineReply {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got := replies()
+		if len(got) >= n || time.Now().After(deadline) {
+			return got
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func lineTextEvent(replyToken, lineUserID, text string) string {
+	return fmt.Sprintf(`{"destination":"U0","events":[{"type":"message","replyToken":%q,`+
+		`"source":{"type":"user","userId":%q},"message":{"id":"1","type":"text","text":%q}}]}`,
+		replyToken, lineUserID, text)
+}
+
+// postLineWebhook delivers body to the agent's webhook signed with secret.
+func postLineWebhook(t *testing.T, h *Handler, agentID uint, secret, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	c, rec := newTestContext(http.MethodPost, "/", body, 0, "agent_id", idParam(agentID))
+	c.Request().Header.Set(line.SignatureHeader, line.Sign(secret, []byte(body)))
+	if err := h.LineWebhook(c); err != nil {
+		t.Fatalf("LineWebhook: %v", err)
+	}
+	return rec
+}
+
+func TestLineWebhookRepliesThroughLineAPI(t *testing.T) {
+	srv, replies := newLineServer(t)
+	h := newTestHandler(t, &llm.EchoProvider{})
+	h.LineAPIURL = srv.URL
+	owner := createTestUser(t, h.DB, "frank")
+	agent := createTestAgent(t, h.DB, models.Agent{
+		Name:                   "Concierge",
+		SystemPrompt:           "You are a concierge.",
+		UserID:                 owner.ID,
+		LineChannelSecret:      "channel-secret",
+		LineChannelAccessToken: "channel-token",
+	})
+
+	for i, text := range []string{"hello", "any rooms?"} {
+		rec := postLineWebhook(t, h, agent.ID, "channel-secret", lineTextEvent(fmt.Sprint("token-", i), "Uline-user", text))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("webhook %d status = %d, body %s", i, rec.Code, rec.Body)
+		}
+	}
+	postLineWebhook(t, h, agent.ID, "channel-secret", lineTextEvent("token-other", "Uother-user", "hi"))
+
+	got := waitForReplies(t, replies, 3)
+	if len(got) != 3 {
+		t.Fatalf("LINE server got %d replies, want 3", len(got))
+	}
+	first := got[0]
+	if first.Path != "/v2/bot/message/reply" || first.Authorization != "Bearer channel-token" || first.ReplyToken != "token-0" {
+		t.Errorf("reply call = %+v", first)
+	}
+	if len(first.Messages) != 1 || first.Messages[0].Type != "text" ||
+		first.Messages[0].Text != "[You are a concierge.] Echo: hello" {
+		t.Errorf("reply messages = %+v", first.Messages)
+	}
+	if got[1].ReplyToken != "token-1" || got[1].Messages[0].Text != "[You are a concierge.] Echo: any rooms?" {
+		t.Errorf("second reply = %+v", got[1])
+	}
+
+	// Each LINE user gets one conversation that keeps their history.
+	var convs []models.Conversation
+	h.DB.Where("agent_id = ?", agent.ID).Order("line_user_id").Find(&convs)
+	if len(convs) != 2 {
+		t.Fatalf("got %d conversations, want one per LINE user: %+v", len(convs), convs)
+	}
+	if convs[0].LineUserID != "Uline-user" || convs[0].UserID != 0 || convs[1].LineUserID != "Uother-user" {
+		t.Errorf("conversations = %+v", convs)
+	}
+	var count int64
+	h.DB.Model(&models.Message{}).Where("conversation_id = ?", convs[0].ID).Count(&count)
+	if count != 4 {
+		t.Errorf("LINE user's conversation has %d messages, want 4", count)
+	}
+}
+
+// gatedProvider answers like the echo provider once release is closed.
+type gatedProvider struct {
+	llm.EchoProvider
+	release chan struct{}
+}
+
+func (p *gatedProvider) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	select {
+	case <-p.release:
+	case <-ctx.Done():
+		return llm.Response{}, ctx.Err()
+	}
+	return p.EchoProvider.Complete(ctx, req)
+}
+
+func TestLineWebhookAcknowledgesBeforeAnswering(t *testing.T) {
+	srv, replies := newLineServer(t)
+	provider := &gatedProvider{release: make(chan struct{})}
+	h := newTestHandler(t, provider)
+	h.LineAPIURL = srv.URL
+	owner := createTestUser(t, h.DB, "erin")
+	agent := createTestAgent(t, h.DB, models.Agent{
+		Name:                   "Concierge",
+		SystemPrompt:           "You are a concierge.",
+		UserID:                 owner.ID,
+		LineChannelSecret:      "channel-secret",
+		LineChannelAccessToken: "channel-token",
+	})
+
+	// The request context ends with the response; answering must outlive it.
+	ctx, cancel := context.WithCancel(context.Background())
+	body := lineTextEvent("token", "Uslow-user", "hello")
+	c, rec := newTestContext(http.MethodPost, "/", body, 0, "agent_id", idParam(agent.ID))
+	c.SetRequest(c.Request().WithContext(ctx))
+	c.Request().Header.Set(line.SignatureHeader, line.Sign("channel-secret", []byte(body)))
+	if err := h.LineWebhook(c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("LineWebhook: %v, status %d", err, rec.Code)
+	}
+	cancel()
+	if n := len(replies()); n != 0 {
+		t.Fatalf("LINE server got %d replies before the provider answered", n)
+	}
+
+	close(provider.release)
+	got := waitForReplies(t, replies, 1)
+	if len(got) != 1 || got[0].ReplyToken != "token" {
+		t.Errorf("replies after the provider answered = %+v", got)
+	}
+}
+
+func TestLineWebhookRejectsBadSignature(t *testing.T) {
+	srv, replies := newLineServer(t)
+	h := newTestHandler(t, &llm.EchoProvider{})
+	h.LineAPIURL = srv.URL
+	owner := createTestUser(t, h.DB, "grace")
+	agent := createTestAgent(t, h.DB, models.Agent{
+		Name:                   "Concierge",
+		SystemPrompt:           "You are a concierge.",
+		UserID:                 owner.ID,
+		LineChannelSecret:      "channel-secret",
+		LineChannelAccessToken: "channel-token",
+	})
+	body := lineTextEvent("token", "Uline-user", "hello")
+
+	rec := postLineWebhook(t, h, agent.ID, "wrong-secret", body)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong secret: status = %d, want 401", rec.Code)
+	}
+
+	c, rec := newTestContext(http.MethodPost, "/", body, 0, "agent_id", idParam(agent.ID))
+	if err := h.LineWebhook(c); err != nil {
+		t.Fatalf("LineWebhook: %v", err)
+	}
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("missing signature: status = %d, want 401", rec.Code)
+	}
+
+	if n := len(replies()); n != 0 {
+		t.Errorf("LINE server got %d replies for rejected webhooks", n)
+	}
+	var count int64
+	h.DB.Model(&models.Conversation{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d conversations created for rejected webhooks", count)
+	}
+}
+
+func TestLineWebhookUnknownAgent(t *testing.T) {
+	h := newTestHandler(t, &llm.EchoProvider{})
+	owner := createTestUser(t, h.DB, "heidi")
+	unlinked := createTestAgent(t, h.DB, models.Agent{Name: "Offline", UserID: owner.ID})
+
+	for _, id := range []uint{unlinked.ID, 9999} {
+		rec := postLineWebhook(t, h, id, "", lineTextEvent("token", "U1", "hi"))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("agent %d: status = %d, want 404", id, rec.Code)
+		}
+	}
+}
+
+func TestLinkAgentToLineOnlySetsLineColumns(t *testing.T) {
+	h := newTestHandler(t, &llm.EchoProvider{})
+	owner := createTestUser(t, h.DB, "ivan")
+	fan := createTestUser(t, h.DB, "judy")
+	agent := createTestAgent(t, h.DB, models.Agent{Name: "Concierge", UserID: owner.ID})
+
+	afterAgentLoad(t, h.DB, func() { likeTestAgent(t, h, agent.ID, fan.ID) })
+
+	c, rec := newTestContext(http.MethodPost, "/", `{"channelSecret":"s","channelAccessToken":"tok"}`,
+		owner.ID, "id", idParam(agent.ID))
+	if err := h.LinkAgentToLine(c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("LinkAgentToLine: %v, status %d, body %s", err, rec.Code, rec.Body)
+	}
+
+	var after models.Agent
+	h.DB.First(&after, agent.ID)
+	if after.LineChannelSecret != "s" || after.LineChannelAccessToken != "tok" || after.LineWebhook == "" {
+		t.Errorf("LINE channel not saved: %+v", after)
+	}
+	if after.LikeCount != 1 {
+		t.Errorf("likeCount = %d; linking overwrote it", after.LikeCount)
+	}
+}
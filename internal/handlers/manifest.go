@@ -0,0 +1,507 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/manifest"
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/prompt"
+	"ai-agent-hub/internal/similar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ========== IMPORT / EXPORT ==========
+
+const (
+	maxImportBytes     = 2 << 20
+	maxImportManifests = 100
+)
+
+// Conflict policies for importing an agent whose name the caller already
+// uses.
+const (
+	onConflictError     = "error"
+	onConflictSkip      = "skip"
+	onConflictRename    = "rename"
+	onConflictOverwrite = "overwrite"
+)
+
+var errDryRun = errors.New("dry run")
+
+// ImportResult says what happened to one imported manifest.
+type ImportResult struct {
+	Source  string `json:"source"`
+	Name    string `json:"name"`
+	Status  string `json:"status"` // created, renamed, updated or skipped
+	AgentID uint   `json:"agentId,omitempty"`
+}
+
+// ImportError reports a manifest that failed validation.
+type ImportError struct {
+	Source string `json:"source"`
+	Name   string `json:"name,omitempty"`
+	Error  string `json:"error"`
+}
+
+// importItem is a decoded manifest and where it came from, e.g.
+// "agents.zip:tutor.agent.yaml#1".
+type importItem struct {
+	source     string
+	manifest   manifest.Manifest
+	tags       []string
+	categoryID *uint
+}
+
+// exportFormat reads ?format=json|yaml, defaulting to JSON.
+func exportFormat(c echo.Context) (manifest.Format, error) {
+	raw := c.QueryParam("format")
+	if raw == "" {
+		return manifest.JSON, nil
+	}
+	f, ok := manifest.ParseFormat(raw)
+	if !ok {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "Invalid format, expected json or yaml")
+	}
+	return f, nil
+}
+
+// exportName turns an agent name into a file name, e.g. "python-tutor-12".
+func exportName(agent models.Agent) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(agent.Name) {
+		if r < 128 && (r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "-")
+	if name == "" {
+		name = "agent"
+	}
+	return fmt.Sprintf("%s-%d", name, agent.ID)
+}
+
+// GET /api/my/agents/:id/export?format=json|yaml
+func (h *Handler) ExportMyAgent(c echo.Context) error {
+	agent, err := h.findMyAgent(c)
+	if err != nil {
+		return err
+	}
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+	if err := h.DB.Scopes(withTaxonomy).First(&agent, agent.ID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to export agent"})
+	}
+
+	data, err := manifest.Encode(manifest.FromAgent(agent, time.Now().UTC()), format)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to export agent"})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q", exportName(agent)+format.Ext()))
+	return c.Blob(http.StatusOK, format.ContentType(), data)
+}
+
+// GET /api/my/agents/export?format=json|yaml
+//
+// Downloads every agent the caller owns as a zip with one manifest each.
+func (h *Handler) ExportMyAgents(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+	format, err := exportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	var agents []models.Agent
+	if err := h.DB.Scopes(withTaxonomy).Where("user_id = ?", userID).Order("id").
+		Find(&agents).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to export agents"})
+	}
+
+	now := time.Now().UTC()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, agent := range agents {
+		data, err := manifest.Encode(manifest.FromAgent(agent, now), format)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to export agents"})
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     exportName(agent) + format.Ext(),
+			Method:   zip.Deflate,
+			Modified: agent.UpdatedAt,
+		})
+		if err == nil {
+			_, err = w.Write(data)
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to export agents"})
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to export agents"})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q", "agents-"+now.Format("20060102")+".zip"))
+	return c.Blob(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// POST /api/my/agents/import?on_conflict=error|skip|rename|overwrite&dry_run=
+//
+// Accepts a manifest as the raw body (JSON, or YAML with a yaml
+// Content-Type or ?format=yaml) or as a multipart "file" upload of a
+// .json, .yaml/.yml or .zip export. A body may hold several manifests: a
+// JSON array or "---"-separated YAML documents.
+//
+// Every manifest is validated before anything is written, and the import
+// is all or nothing. A conflict is an agent of the caller's with the same
+// name (case-insensitive); by default it fails the import with 409.
+// dry_run=true reports what would happen without saving.
+func (h *Handler) ImportMyAgents(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	onConflict := c.QueryParam("on_conflict")
+	switch onConflict {
+	case "":
+		onConflict = onConflictError
+	case onConflictError, onConflictSkip, onConflictRename, onConflictOverwrite:
+	default:
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid on_conflict, expected error, skip, rename or overwrite"})
+	}
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+
+	if err := h.checkCanCreateAgents(userID); err != nil {
+		return err
+	}
+
+	items, err := readImport(c)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "No agent manifests found"})
+	}
+	if len(items) > maxImportManifests {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("At most %d agents can be imported at once", maxImportManifests)})
+	}
+
+	if invalid := h.validateImport(items); len(invalid) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": "Invalid agent manifests", "errors": invalid})
+	}
+
+	var owned []models.Agent
+	if err := h.DB.Where("user_id = ?", userID).Find(&owned).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Database error"})
+	}
+	byName := make(map[string]models.Agent, len(owned))
+	for _, a := range owned {
+		byName[strings.ToLower(a.Name)] = a
+	}
+
+	if onConflict == onConflictError {
+		var conflicts []ImportError
+		seen := map[string]bool{}
+		for _, it := range items {
+			key := strings.ToLower(it.manifest.Metadata.Name)
+			if _, ok := byName[key]; ok || seen[key] {
+				conflicts = append(conflicts, ImportError{Source: it.source, Name: it.manifest.Metadata.Name, Error: "An agent with this name already exists"})
+			}
+			seen[key] = true
+		}
+		if len(conflicts) > 0 {
+			return c.JSON(http.StatusConflict, echo.Map{"error": "Agents with these names already exist", "errors": conflicts})
+		}
+	}
+
+	results := make([]ImportResult, 0, len(items))
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		for _, it := range items {
+			res, err := importAgent(tx, userID, it, onConflict, byName)
+			if err != nil {
+				return err
+			}
+			results = append(results, res)
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to import agents"})
+	}
+
+	if !dryRun {
+		for _, res := range results {
+			if res.AgentID != 0 {
+				similar.MarkDirty(res.AgentID)
+			}
+		}
+	}
+	return c.JSON(http.StatusOK, echo.Map{"dryRun": dryRun, "results": results})
+}
+
+// importAgent writes one validated manifest according to onConflict and
+// records the result in byName so later manifests see it.
+func importAgent(tx *gorm.DB, userID uint, it importItem, onConflict string, byName map[string]models.Agent) (ImportResult, error) {
+	m := it.manifest
+	res := ImportResult{Source: it.source, Name: m.Metadata.Name, Status: "created"}
+
+	existing, conflict := byName[strings.ToLower(m.Metadata.Name)]
+	if conflict {
+		switch onConflict {
+		case onConflictSkip:
+			res.Status, res.AgentID = "skipped", existing.ID
+			return res, nil
+		case onConflictOverwrite:
+			res.Status = "updated"
+		case onConflictRename:
+			res.Status = "renamed"
+			res.Name = uniqueAgentName(m.Metadata.Name, byName)
+		}
+	}
+
+	agent := models.Agent{UserID: userID}
+	if res.Status == "updated" {
+		agent = existing
+		if err := ensureInitialVersion(tx, agent); err != nil {
+			return res, err
+		}
+	}
+	agent.Name = res.Name
+	agent.Description = m.Metadata.Description
+	agent.SystemPrompt = m.Spec.SystemPrompt
+	agent.InputTemplate = m.Spec.InputTemplate
+	agent.Personality = m.Spec.Personality
+	agent.AllowForks = m.Spec.AllowForks
+	agent.CategoryID = it.categoryID
+	if m.Metadata.Avatar != "" {
+		agent.Avatar = m.Metadata.Avatar
+	}
+	if err := setVisibility(&agent, m.Spec.Visibility); err != nil {
+		return res, err
+	}
+
+	if res.Status == "updated" {
+		// Only what the manifest describes is written; an agent imported
+		// without an avatar keeps its own.
+		q := tx.Model(&agent).Select(editableAgentColumns)
+		if m.Metadata.Avatar == "" {
+			q = q.Omit("avatar")
+		}
+		if err := q.Updates(&agent).Error; err != nil {
+			return res, err
+		}
+		if err := setAgentTags(tx, &agent, it.tags); err != nil {
+			return res, err
+		}
+		if err := recordAgentVersion(tx, agent, userID, "Imported"); err != nil {
+			return res, err
+		}
+	} else if err := createAgent(tx, &agent, it.tags, "Imported"); err != nil {
+		return res, err
+	}
+
+	res.AgentID = agent.ID
+	byName[strings.ToLower(agent.Name)] = agent
+	return res, nil
+}
+
+// uniqueAgentName appends " (2)", " (3)", ... until name is unused.
+func uniqueAgentName(name string, byName map[string]models.Agent) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if _, taken := byName[strings.ToLower(candidate)]; !taken {
+			return candidate
+		}
+	}
+}
+
+// validateImport checks every manifest and resolves its tags and category,
+// returning all problems at once so a file can be fixed in one pass.
+func (h *Handler) validateImport(items []importItem) []ImportError {
+	var invalid []ImportError
+	for i := range items {
+		it := &items[i]
+		fail := func(err error) {
+			msg := err.Error()
+			if he, ok := err.(*echo.HTTPError); ok {
+				msg = fmt.Sprint(he.Message)
+			}
+			invalid = append(invalid, ImportError{Source: it.source, Name: it.manifest.Metadata.Name, Error: msg})
+		}
+
+		m := &it.manifest
+		m.Metadata.Name = strings.TrimSpace(m.Metadata.Name)
+		if err := m.Validate(); err != nil {
+			fail(err)
+			continue
+		}
+		if err := prompt.Validate(m.Spec.InputTemplate); err != nil {
+			fail(fmt.Errorf("invalid input template: %w", err))
+			continue
+		}
+		tags, err := normalizeTags(m.Metadata.Tags)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		it.tags = tags
+		if it.categoryID, err = resolveCategory(h.DB, m.Metadata.Category); err != nil {
+			fail(err)
+		}
+	}
+	return invalid
+}
+
+// readImport reads and decodes the manifests in an import request.
+func readImport(c echo.Context) ([]importItem, error) {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxImportBytes+64<<10)
+	tooBig := echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Import must be at most 2 MB")
+
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return nil, tooBig
+			}
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Missing file")
+		}
+		if fh.Size > maxImportBytes {
+			return nil, tooBig
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Failed to read file")
+		}
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Failed to read file")
+		}
+
+		ext := strings.ToLower(path.Ext(fh.Filename))
+		if ext == ".zip" {
+			return readImportZip(fh.Filename, data)
+		}
+		format, ok := manifest.ParseFormat(ext)
+		if !ok {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "File must be .json, .yaml, .yml or .zip")
+		}
+		return decodeImport(fh.Filename, data, format)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, tooBig
+		}
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Failed to read request body")
+	}
+	if len(data) > maxImportBytes {
+		return nil, tooBig
+	}
+
+	format := manifest.JSON
+	if raw := c.QueryParam("format"); raw != "" {
+		f, ok := manifest.ParseFormat(raw)
+		if !ok {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid format, expected json or yaml")
+		}
+		format = f
+	} else if strings.Contains(req.Header.Get(echo.HeaderContentType), "yaml") {
+		format = manifest.YAML
+	}
+	return decodeImport("body", data, format)
+}
+
+// readImportZip decodes every .json/.yaml/.yml file in a zip, such as one
+// made by ExportMyAgents. Other files are ignored.
+func readImportZip(name string, data []byte) ([]importItem, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid zip file")
+	}
+
+	var items []importItem
+	var total int64
+	for _, f := range zr.File {
+		base := path.Base(f.Name)
+		if f.FileInfo().IsDir() || strings.HasPrefix(base, ".") || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		format, ok := manifest.ParseFormat(path.Ext(base))
+		if !ok {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid zip file")
+		}
+		// Guard against zip bombs: what is actually inflated, not what the
+		// headers claim, is held to the same limit as a plain upload.
+		content, err := io.ReadAll(io.LimitReader(rc, maxImportBytes-total+1))
+		rc.Close()
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid zip file")
+		}
+		total += int64(len(content))
+		if total > maxImportBytes {
+			return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Import must be at most 2 MB uncompressed")
+		}
+
+		decoded, err := decodeImport(name+":"+f.Name, content, format)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, decoded...)
+		if len(items) > maxImportManifests {
+			break
+		}
+	}
+	return items, nil
+}
+
+func decodeImport(source string, data []byte, format manifest.Format) ([]importItem, error) {
+	manifests, err := manifest.Decode(data, format)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, source+": "+err.Error())
+	}
+
+	items := make([]importItem, len(manifests))
+	for i, m := range manifests {
+		items[i] = importItem{source: source, manifest: m}
+		if len(manifests) > 1 {
+			items[i].source = fmt.Sprintf("%s#%d", source, i+1)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/manifest"
+	"ai-agent-hub/internal/models"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestImportOverwriteKeepsConcurrentChanges(t *testing.T) {
+	h := newTestHandler(t, &llm.EchoProvider{})
+	owner := createTestUser(t, h.DB, "owner")
+	fan := createTestUser(t, h.DB, "fan")
+	agent := createTestAgent(t, h.DB, models.Agent{Name: "Helper", Avatar: "/old.png", UserID: owner.ID})
+	byName := map[string]models.Agent{strings.ToLower(agent.Name): agent}
+
+	// Between the import loading the owner's agents and writing them, the
+	// agent is liked, featured and given a new avatar.
+	likeTestAgent(t, h, agent.ID, fan.ID)
+	h.DB.Model(&models.Agent{}).Where("id = ?", agent.ID).
+		UpdateColumns(map[string]any{"is_featured": true, "avatar": "/new.png"})
+
+	it := importItem{source: "helper.yaml", manifest: manifest.Manifest{
+		Metadata: manifest.Metadata{Name: "Helper", Description: "Imported"},
+		Spec:     manifest.Spec{SystemPrompt: "Help."},
+	}}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		res, err := importAgent(tx, owner.ID, it, onConflictOverwrite, byName)
+		if err == nil && res.Status != "updated" {
+			t.Errorf("status = %q, want updated", res.Status)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("importAgent: %v", err)
+	}
+
+	var after models.Agent
+	h.DB.First(&after, agent.ID)
+	if after.Description != "Imported" || after.SystemPrompt != "Help." {
+		t.Errorf("manifest not applied: %+v", after)
+	}
+	if after.LikeCount != 1 || !after.IsFeatured {
+		t.Errorf("likeCount = %d, isFeatured = %v; the import overwrote them", after.LikeCount, after.IsFeatured)
+	}
+	if after.Avatar != "/new.png" {
+		t.Errorf("avatar = %q, want the current one kept", after.Avatar)
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/auth"
+	"ai-agent-hub/internal/dto"
+	"ai-agent-hub/internal/models"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ========== PROFILE ==========
+
+func (h *Handler) countAgents(userID uint, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+	err := h.DB.Model(&models.Agent{}).Scopes(scopes...).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// GET /api/my/profile
+func (h *Handler) GetProfile(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, userID).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "User not found"})
+	}
+
+	count, err := h.countAgents(user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Database error"})
+	}
+
+	return c.JSON(http.StatusOK, dto.NewMyProfile(user, count))
+}
+
+// PUT /api/my/profile
+//
+// Only the fields present in the body are changed. Changing the password
+// requires the current one and a signed-in session, since it signs out every
+// other session; API keys can't change it.
+func (h *Handler) UpdateProfile(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	type UpdateProfileRequest struct {
+		Username        *string `json:"username" validate:"omitempty,min=3,max=32"`
+		Bio             *string `json:"bio" validate:"omitempty,max=500"`
+		Avatar          *string `json:"avatar" validate:"omitempty,avatar"`
+		CurrentPassword string  `json:"currentPassword"`
+		NewPassword     string  `json:"newPassword" validate:"omitempty,min=6"`
+	}
+
+	var req UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if req.NewPassword != "" && currentSessionID(c) == "" {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "Changing the password needs a signed-in session, not an API key"})
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, userID).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "User not found"})
+	}
+
+	if req.Username != nil && *req.Username != user.Username {
+		var existing models.User
+		if err := h.DB.Where("username = ?", *req.Username).First(&existing).Error; err != gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusConflict, echo.Map{"error": "Username is already taken"})
+		}
+		user.Username = *req.Username
+	}
+	if req.Bio != nil {
+		user.Bio = strings.TrimSpace(*req.Bio)
+	}
+	// An avatar set by URL replaces any upload, whose files are removed.
+	oldAvatarKey := ""
+	if req.Avatar != nil && *req.Avatar != user.Avatar {
+		user.Avatar = *req.Avatar
+		oldAvatarKey, user.AvatarKey = user.AvatarKey, ""
+	}
+	if req.NewPassword != "" {
+		if err := user.CheckPassword(req.CurrentPassword); err != nil {
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Current password is incorrect"})
+		}
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to hash password"})
+		}
+		user.Password = string(hashed)
+	}
+
+	if err := h.DB.Save(&user).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to update profile"})
+	}
+	h.removeUserAvatar(c, user.ID, oldAvatarKey)
+
+	// A new password signs out every other device.
+	if req.NewPassword != "" {
+		if err := auth.RevokeOtherSessions(h.DB, user.ID, currentSessionID(c)); err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to revoke sessions"})
+		}
+	}
+
+	count, err := h.countAgents(user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Database error"})
+	}
+
+	return c.JSON(http.StatusOK, dto.NewMyProfile(user, count))
+}
+
+// GET /api/users/:username
+func (h *Handler) GetUserProfile(c echo.Context) error {
+	var user models.User
+	if err := h.DB.Where("username = ?", c.Param("username")).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusNotFound, echo.Map{"error": "User not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Database error"})
+	}
+
+	count, err := h.countAgents(user.ID, publicAgents)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Database error"})
+	}
+
+	return c.JSON(http.StatusOK, dto.NewPublicProfile(user, count))
+}
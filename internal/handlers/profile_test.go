@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordChangeRevokesOnlyOtherSessions(t *testing.T) {
+	h := newTestHandler(t, &llm.EchoProvider{})
+	user := createTestUser(t, h.DB, "pat")
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("old-secret"), bcrypt.MinCost)
+	h.DB.Model(&user).Update("password", string(hashed))
+	for _, family := range []string{"this-device", "other-device"} {
+		h.DB.Create(&models.RefreshToken{UserID: user.ID, FamilyID: family, TokenHash: family})
+	}
+	body := `{"currentPassword":"old-secret","newPassword":"new-secret"}`
+
+	revoked := func() map[string]bool {
+		var tokens []models.RefreshToken
+		h.DB.Where("user_id = ?", user.ID).Find(&tokens)
+		out := map[string]bool{}
+		for _, rt := range tokens {
+			out[rt.FamilyID] = rt.RevokedAt != nil
+		}
+		return out
+	}
+
+	// An API key has no session to keep, so it may not change the password.
+	c, rec := newTestContext(http.MethodPut, "/", body, user.ID)
+	c.Set("user", &jwt.Token{Valid: true, Claims: jwt.MapClaims{"user_id": float64(user.ID), "api_key_id": float64(1)}})
+	if err := h.UpdateProfile(c); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("API key password change: status = %d, want 403", rec.Code)
+	}
+	if r := revoked(); r["this-device"] || r["other-device"] {
+		t.Errorf("API key request revoked sessions: %v", r)
+	}
+
+	c, rec = newTestContext(http.MethodPut, "/", body, user.ID)
+	c.Set("user", &jwt.Token{Valid: true, Claims: jwt.MapClaims{"user_id": float64(user.ID), "sid": "this-device"}})
+	if err := h.UpdateProfile(c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("UpdateProfile: %v, status %d, body %s", err, rec.Code, rec.Body)
+	}
+	if r := revoked(); r["this-device"] || !r["other-device"] {
+		t.Errorf("revoked sessions = %v, want only the other device", r)
+	}
+
+	var after models.User
+	h.DB.First(&after, user.ID)
+	if after.CheckPassword("new-secret") != nil {
+		t.Error("password not changed")
+	}
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/dto"
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========== REVIEWS ==========
+
+// refreshAgentRating recomputes an agent's denormalized rating from its
+// reviews. Recomputing instead of adjusting keeps it correct under edits.
+func refreshAgentRating(tx *gorm.DB, agentID uint) error {
+	return tx.Exec(`
+		UPDATE agents SET
+			rating_count   = (SELECT COUNT(*) FROM reviews WHERE agent_id = ?),
+			rating_average = (SELECT COALESCE(AVG(rating), 0) FROM reviews WHERE agent_id = ?)
+		WHERE id = ?`, agentID, agentID, agentID).Error
+}
+
+var reviewListSpec = utils.ListSpec{
+	Sorts: map[string]string{
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+		"rating":     "rating",
+	},
+	DefaultSort: "-updated_at",
+	Filters: map[string]utils.Filter{
+		"rating": {Column: "rating", Kind: utils.FilterNumber},
+	},
+	DateRanges: map[string]string{
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	Key:   "id",
+	Model: &models.Review{},
+}
+
+// GET /api/agents/:id/reviews
+func (h *Handler) GetAgentReviews(c echo.Context) error {
+	lq, err := utils.ParseListQuery(c, reviewListSpec)
+	if err != nil {
+		return err
+	}
+	agent, err := h.findAgent(c)
+	if err != nil {
+		return err
+	}
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		return db.Where("agent_id = ?", agent.ID).Scopes(lq.Where)
+	}
+
+	var total int64
+	if err := h.DB.Model(&models.Review{}).Scopes(scope).Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch reviews"})
+	}
+
+	var reviews []models.Review
+	if err := h.DB.Preload("User").Scopes(scope, lq.Paginate).Find(&reviews).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch reviews"})
+	}
+
+	reviews, page := utils.FinishPage(lq, reviews)
+
+	out := make([]dto.Review, len(reviews))
+	for i, r := range reviews {
+		out[i] = dto.NewReview(r)
+	}
+
+	resp := lq.Response(out, page, total)
+	utils.SetLinkHeader(c, resp)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// PUT /api/agents/:id/review
+//
+// Creates or edits the caller's review of an agent.
+func (h *Handler) UpsertAgentReview(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	var req struct {
+		Rating int    `json:"rating" validate:"required,min=1,max=5"`
+		Body   string `json:"body" validate:"max=2000"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	agent, err := h.findAgent(c)
+	if err != nil {
+		return err
+	}
+	if agent.UserID == userID {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "You cannot review your own agent"})
+	}
+
+	// A single upsert, so that two concurrent first reviews by the same user
+	// can't both insert. A created row is told apart by its timestamps: an
+	// update moves updated_at past created_at.
+	review := models.Review{AgentID: agent.ID, UserID: userID, Rating: req.Rating, Body: strings.TrimSpace(req.Body)}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "agent_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rating", "body", "updated_at"}),
+		}).Create(&review).Error; err != nil {
+			return err
+		}
+		if err := refreshAgentRating(tx, agent.ID); err != nil {
+			return err
+		}
+		return tx.Preload("User").Where("agent_id = ? AND user_id = ?", agent.ID, userID).First(&review).Error
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to save review"})
+	}
+
+	status := http.StatusOK
+	if review.CreatedAt.Equal(review.UpdatedAt) {
+		status = http.StatusCreated
+	}
+	return c.JSON(status, dto.NewReview(review))
+}
+
+// DELETE /api/agents/:id/review
+func (h *Handler) DeleteAgentReview(c echo.Context) error {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+	agent, err := h.findAgent(c)
+	if err != nil {
+		return err
+	}
+
+	var deleted int64
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("agent_id = ? AND user_id = ?", agent.ID, userID).Delete(&models.Review{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted = res.RowsAffected
+		return refreshAgentRating(tx, agent.ID)
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to delete review"})
+	}
+	if deleted == 0 {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "Review not found"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PUT /api/my/agents/:id/reviews/:review_id/reply
+//
+// Lets the agent's owner answer a review. An empty reply removes it.
+func (h *Handler) ReplyToReview(c echo.Context) error {
+	agent, err := h.findMyAgent(c)
+	if err != nil {
+		return err
+	}
+
+	var req struct {
+		Reply string `json:"reply" validate:"max=2000"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	var review models.Review
+	if err := h.DB.Preload("User").Where("id = ? AND agent_id = ?", c.Param("review_id"), agent.ID).
+		First(&review).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "Review not found"})
+	}
+
+	review.OwnerReply = strings.TrimSpace(req.Reply)
+	review.OwnerRepliedAt = nil
+	if review.OwnerReply != "" {
+		now := time.Now()
+		review.OwnerRepliedAt = &now
+	}
+
+	// Replying must not bump updated_at, which orders reviews by the
+	// reviewer's own activity.
+	if err := h.DB.Model(&review).UpdateColumns(map[string]any{
+		"owner_reply":      review.OwnerReply,
+		"owner_replied_at": review.OwnerRepliedAt,
+	}).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to save reply"})
+	}
+
+	return c.JSON(http.StatusOK, dto.NewReview(review))
+}
+
+// GET /api/agents/top-rated?min_reviews=
+func (h *Handler) GetTopRatedAgents(c echo.Context) error {
+	minReviews, _ := strconv.Atoi(c.QueryParam("min_reviews"))
+	if minReviews < 1 {
+		minReviews = 1
+	}
+
+	return h.listAgents(c, agentListSpec("-rating_average,-rating_count"), func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(publicAgents).Where("agents.rating_count >= ?", minReviews)
+	})
+}
+
+// DELETE /api/admin/reviews/:id?reason=
+func (h *Handler) AdminDeleteReview(c echo.Context) error {
+	actorID, _ := currentUserID(c)
+
+	var review models.Review
+	if err := h.DB.First(&review, "id = ?", c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "Review not found"})
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&review).Error; err != nil {
+			return err
+		}
+		if err := refreshAgentRating(tx, review.AgentID); err != nil {
+			return err
+		}
+		return logModeration(tx, actorID, "delete_review", "review", review.ID, c.QueryParam("reason"))
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to delete review"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/models"
+	"net/http"
+	"testing"
+)
+
+func putReview(t *testing.T, h *Handler, agentID, userID uint, body string) int {
+	t.Helper()
+	c, rec := newTestContext(http.MethodPut, "/", body, userID, "id", idParam(agentID))
+	if err := h.UpsertAgentReview(c); err != nil {
+		t.Fatalf("UpsertAgentReview: %v", err)
+	}
+	return rec.Code
+}
+
+func TestUpsertAgentReview(t *testing.T) {
+	h := newTestHandler(t, &llm.EchoProvider{})
+	owner := createTestUser(t, h.DB, "owner")
+	alice := createTestUser(t, h.DB, "alice")
+	bob := createTestUser(t, h.DB, "bob")
+	agent := createTestAgent(t, h.DB, models.Agent{Name: "Rated", UserID: owner.ID})
+
+	if code := putReview(t, h, agent.ID, alice.ID, `{"rating":4,"body":"Good"}`); code != http.StatusCreated {
+		t.Errorf("first review: status = %d, want 201", code)
+	}
+	if code := putReview(t, h, agent.ID, alice.ID, `{"rating":2,"body":"Worse now"}`); code != http.StatusOK {
+		t.Errorf("edited review: status = %d, want 200", code)
+	}
+
+	// Bob's review lands from another request between this one loading the
+	// agent and saving; the upsert edits it instead of failing.
+	afterAgentLoad(t, h.DB, func() {
+		h.DB.Create(&models.Review{AgentID: agent.ID, UserID: bob.ID, Rating: 1})
+	})
+	if code := putReview(t, h, agent.ID, bob.ID, `{"rating":5}`); code != http.StatusOK {
+		t.Errorf("review racing another: status = %d, want 200", code)
+	}
+
+	var reviews []models.Review
+	h.DB.Where("agent_id = ?", agent.ID).Order("user_id").Find(&reviews)
+	if len(reviews) != 2 || reviews[0].Rating != 2 || reviews[0].Body != "Worse now" || reviews[1].Rating != 5 {
+		t.Fatalf("reviews = %+v", reviews)
+	}
+	var after models.Agent
+	h.DB.First(&after, agent.ID)
+	if after.RatingCount != 2 || after.RatingAverage != 3.5 {
+		t.Errorf("rating = %v over %d reviews, want 3.5 over 2", after.RatingAverage, after.RatingCount)
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/utils"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ========== SEARCH ==========
+
+const maxSearchQueryLength = 200
+
+// ts_headline marks matched terms with private-use characters rather than
+// tags, so that highlightHTML can escape the agent's text before turning
+// the marks into <mark>…</mark>.
+const (
+	headlineStart        = "\uE000"
+	headlineStop         = "\uE001"
+	headlineOptions      = "StartSel=\"" + headlineStart + "\", StopSel=\"" + headlineStop + "\", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+	headlineAllOptions   = "StartSel=\"" + headlineStart + "\", StopSel=\"" + headlineStop + "\", HighlightAll=true"
+	rankNormalizeToUnity = 32 // rank / (rank + 1)
+)
+
+var headlineMarks = strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>")
+
+// highlightHTML turns a ts_headline result into safe HTML: the text is
+// escaped and only the matched terms are wrapped in <mark>.
+func highlightHTML(headline string) string {
+	return headlineMarks.Replace(html.EscapeString(headline))
+}
+
+// SearchHighlights are HTML: the agent's text, escaped, with matched terms
+// wrapped in <mark>…</mark>.
+type SearchHighlights struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Personality string `json:"personality"`
+}
+
+// SearchResult is an agent matched by GET /api/agents/search.
+type SearchResult struct {
+	models.Agent
+	Rank       float64          `json:"rank"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+type searchHit struct {
+	ID          uint
+	Rank        float64
+	Name        string
+	Description string
+	Personality string
+}
+
+// GET /api/agents/search?q=
+func (h *Handler) SearchAgents(c echo.Context) error {
+	q := strings.TrimSpace(c.QueryParam("q"))
+	if q == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Query parameter q is required"})
+	}
+	if len([]rune(q)) > maxSearchQueryLength {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Search query is too long"})
+	}
+
+	// Results are ordered by relevance unless ?sort= is given. Rank isn't a
+	// column, so search pages by number only.
+	spec := agentListSpec("")
+	spec.Model = nil
+	lq, err := utils.ParseListQuery(c, spec)
+	if err != nil {
+		return err
+	}
+	taxonomy, err := taxonomyFilter(c)
+	if err != nil {
+		return err
+	}
+	order := func(db *gorm.DB) *gorm.DB {
+		if c.QueryParam("sort") != "" {
+			return lq.Order(db)
+		}
+		return db.Order("rank DESC, agents.view_count DESC, agents.id")
+	}
+
+	// websearch_to_tsquery accepts what users type ("quoted phrases", or,
+	// -exclusions) and never fails on stray punctuation.
+	scope := func(db *gorm.DB) *gorm.DB {
+		return db.Joins("CROSS JOIN websearch_to_tsquery('english', ?) AS query", q).
+			Where("agents.search_vector @@ query").
+			Scopes(publicAgents, lq.Where, taxonomy)
+	}
+
+	var total int64
+	if err := h.DB.Model(&models.Agent{}).Scopes(scope).Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Search failed"})
+	}
+
+	var hits []searchHit
+	if err := h.DB.Model(&models.Agent{}).Scopes(scope).
+		Select(`agents.id,
+			ts_rank_cd(agents.search_vector, query, ?) AS rank,
+			ts_headline('english', agents.name, query, ?) AS name,
+			ts_headline('english', agents.description, query, ?) AS description,
+			ts_headline('english', agents.personality, query, ?) AS personality`,
+			rankNormalizeToUnity, headlineAllOptions, headlineOptions, headlineOptions).
+		Scopes(order).
+		Limit(lq.Limit + 1).Offset(lq.Offset).
+		Scan(&hits).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Search failed"})
+	}
+
+	hasMore := len(hits) > lq.Limit
+	if hasMore {
+		hits = hits[:lq.Limit]
+	}
+
+	results, err := h.loadSearchResults(c, hits)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Search failed"})
+	}
+
+	resp := lq.Response(results, utils.PageInfo{HasMore: hasMore}, total)
+	utils.SetLinkHeader(c, resp)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// loadSearchResults loads the agents behind ranked hits, keeping the ranking
+// order.
+func (h *Handler) loadSearchResults(c echo.Context, hits []searchHit) ([]SearchResult, error) {
+	results := []SearchResult{}
+	if len(hits) == 0 {
+		return results, nil
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	var agents []models.Agent
+	if err := h.DB.Scopes(withTaxonomy).Where("id IN ?", ids).Find(&agents).Error; err != nil {
+		return nil, err
+	}
+	h.markLiked(c, agents)
+
+	byID := make(map[uint]models.Agent, len(agents))
+	for _, a := range agents {
+		byID[a.ID] = a
+	}
+
+	for _, hit := range hits {
+		agent, ok := byID[hit.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{
+			Agent: agent,
+			Rank:  hit.Rank,
+			Highlights: SearchHighlights{
+				Name:        highlightHTML(hit.Name),
+				Description: highlightHTML(hit.Description),
+				Personality: highlightHTML(hit.Personality),
+			},
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import "testing"
+
+func TestHighlightHTML(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{"plain text", "plain text"},
+		{"a " + headlineStart + "match" + headlineStop + " here", "a <mark>match</mark> here"},
+		{
+			`<img src=x onerror="alert(1)"> ` + headlineStart + "cat" + headlineStop,
+			`&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>cat</mark>`,
+		},
+		{headlineStart + "<b>" + headlineStop + " & 'co'", "<mark>&lt;b&gt;</mark> &amp; &#39;co&#39;"},
+		{"one … two", "one … two"},
+	}
+	for _, tt := range tests {
+		if got := highlightHTML(tt.headline); got != tt.want {
+			t.Errorf("highlightHTML(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/similar"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== SIMILAR AGENTS ==========
+
+// SimilarAgent is an agent suggested by GET /api/agents/:id/similar.
+type SimilarAgent struct {
+	models.Agent
+	Similarity float64 `json:"similarity"`
+}
+
+// GET /api/agents/:id/similar?limit=
+//
+// Served from the cache kept by the similar-agents job, so a brand new
+// agent has no suggestions until the next refresh a few seconds later.
+// Only public agents are suggested.
+func (h *Handler) GetSimilarAgents(c echo.Context) error {
+	agent, err := h.findAgent(c)
+	if err != nil {
+		return err
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > similar.TopK {
+		limit = similar.TopK
+	}
+
+	var matches []models.AgentSimilarity
+	if err := h.DB.Joins("JOIN agents ON agents.id = agent_similarities.similar_agent_id AND agents.deleted_at IS NULL").
+		Scopes(publicAgents).Where("agent_similarities.agent_id = ?", agent.ID).
+		Order("agent_similarities.rank").Limit(limit).Find(&matches).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch similar agents"})
+	}
+
+	out := []SimilarAgent{}
+	if len(matches) == 0 {
+		return c.JSON(http.StatusOK, echo.Map{"data": out})
+	}
+
+	ids := make([]uint, len(matches))
+	for i, m := range matches {
+		ids[i] = m.SimilarAgentID
+	}
+
+	var agents []models.Agent
+	if err := h.DB.Scopes(withTaxonomy, publicAgents).Where("id IN ?", ids).Find(&agents).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch similar agents"})
+	}
+	h.markLiked(c, agents)
+
+	byID := make(map[uint]models.Agent, len(agents))
+	for _, a := range agents {
+		byID[a.ID] = a
+	}
+	// Agents deleted or hidden since the last refresh are skipped.
+	for _, m := range matches {
+		if a, ok := byID[m.SimilarAgentID]; ok {
+			out = append(out, SimilarAgent{Agent: a, Similarity: m.Score})
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"data": out})
+}
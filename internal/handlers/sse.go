@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// sseWriter writes Server-Sent Events to the response, flushing after each
+// event so clients see them immediately.
+type sseWriter struct {
+	res     *echo.Response
+	started bool
+}
+
+func newSSEWriter(c echo.Context) *sseWriter {
+	return &sseWriter{res: c.Response()}
+}
+
+func (w *sseWriter) start() {
+	if w.started {
+		return
+	}
+	h := w.res.Header()
+	h.Set(echo.HeaderContentType, "text/event-stream")
+	h.Set(echo.HeaderCacheControl, "no-cache")
+	h.Set(echo.HeaderConnection, "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // disable proxy buffering (nginx)
+	w.res.WriteHeader(http.StatusOK)
+	w.started = true
+}
+
+// Event sends a single named event with a JSON-encoded payload.
+func (w *sseWriter) Event(name string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	w.start()
+	if _, err := fmt.Fprintf(w.res, "event: %s\ndata: %s\n\n", name, payload); err != nil {
+		return err
+	}
+	w.res.Flush()
+	return nil
+}
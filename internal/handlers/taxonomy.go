@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/models"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========== CATEGORIES & TAGS ==========
+
+const (
+	maxTagsPerAgent = 10
+	maxTagLength    = 32
+)
+
+var (
+	tagPattern  = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N}+#.-]*$`)
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+)
+
+// normalizeTags lower-cases tags, turns inner whitespace into dashes and
+// drops duplicates, keeping the caller's order.
+func normalizeTags(raw []string) ([]string, error) {
+	seen := map[string]bool{}
+	tags := []string{}
+
+	for _, t := range raw {
+		t = strings.ToLower(strings.Join(strings.Fields(t), "-"))
+		if t == "" || seen[t] {
+			continue
+		}
+		if len([]rune(t)) > maxTagLength || !tagPattern.MatchString(t) {
+			return nil, fmt.Errorf("invalid tag %q", t)
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+
+	if len(tags) > maxTagsPerAgent {
+		return nil, fmt.Errorf("an agent can have at most %d tags", maxTagsPerAgent)
+	}
+	return tags, nil
+}
+
+// setAgentTags replaces an agent's tags, creating tags that don't exist yet.
+func setAgentTags(tx *gorm.DB, agent *models.Agent, names []string) error {
+	tags := []models.Tag{}
+	if len(names) > 0 {
+		create := make([]models.Tag, len(names))
+		for i, n := range names {
+			create[i] = models.Tag{Name: n}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&create).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(agent).Association("Tags").Replace(tags)
+}
+
+// resolveCategory maps a category slug to its ID. An empty slug clears the
+// category.
+func resolveCategory(tx *gorm.DB, slug string) (*uint, error) {
+	if slug == "" {
+		return nil, nil
+	}
+	var category models.Category
+	if err := tx.Where("slug = ?", slug).First(&category).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Unknown category: "+slug)
+		}
+		return nil, err
+	}
+	return &category.ID, nil
+}
+
+// withTaxonomy preloads an agent's category and tags.
+func withTaxonomy(db *gorm.DB) *gorm.DB {
+	return db.Preload("Category").Preload("Tags")
+}
+
+// taxonomyFilter applies ?category=<slug> and ?tag=<name> (repeatable; an
+// agent must carry every tag given). Tags are checked like on create, and
+// a bad one is a 400 rather than silently ignored.
+func taxonomyFilter(c echo.Context) (func(*gorm.DB) *gorm.DB, error) {
+	category := c.QueryParam("category")
+	tags, err := normalizeTags(c.QueryParams()["tag"])
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if category != "" {
+			db = db.Where("agents.category_id = (SELECT id FROM categories WHERE slug = ?)", category)
+		}
+		for _, t := range tags {
+			db = db.Where(`EXISTS (SELECT 1 FROM agent_tags JOIN tags ON tags.id = agent_tags.tag_id
+				WHERE agent_tags.agent_id = agents.id AND tags.name = ?)`, t)
+		}
+		return db
+	}, nil
+}
+
+// GET /api/categories
+func (h *Handler) GetCategories(c echo.Context) error {
+	type CategoryWithCount struct {
+		models.Category
+		AgentCount int64 `json:"agentCount"`
+	}
+
+	var categories []CategoryWithCount
+	if err := h.DB.Model(&models.Category{}).
+		Select(`categories.*, (SELECT COUNT(*) FROM agents WHERE agents.category_id = categories.id
+			AND agents.deleted_at IS NULL AND agents.visibility = ?) AS agent_count`, models.VisibilityPublic).
+		Order("name").Scan(&categories).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch categories"})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"data": categories})
+}
+
+// GET /api/tags?limit=
+//
+// Tag cloud: the most used tags with how many agents carry each.
+func (h *Handler) GetTagCloud(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	type TagCount struct {
+		Name  string `json:"name"`
+		Count int64  `json:"count"`
+	}
+
+	var tags []TagCount
+	if err := h.DB.Table("tags").
+		Select("tags.name, COUNT(*) AS count").
+		Joins("JOIN agent_tags ON agent_tags.tag_id = tags.id").
+		Joins("JOIN agents ON agents.id = agent_tags.agent_id AND agents.deleted_at IS NULL AND agents.visibility = ?", models.VisibilityPublic).
+		Group("tags.name").
+		Order("count desc, tags.name").
+		Limit(limit).
+		Scan(&tags).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch tags"})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"data": tags})
+}
+
+type CategoryRequest struct {
+	Slug        string `json:"slug" validate:"required,max=64"`
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+func bindCategory(c echo.Context) (CategoryRequest, error) {
+	var req CategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return req, echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	req.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+	if err := c.Validate(&req); err != nil {
+		return req, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if !slugPattern.MatchString(req.Slug) {
+		return req, echo.NewHTTPError(http.StatusBadRequest, "Slug may only contain lower-case letters, digits and dashes")
+	}
+	return req, nil
+}
+
+// POST /api/admin/categories
+func (h *Handler) AdminCreateCategory(c echo.Context) error {
+	req, err := bindCategory(c)
+	if err != nil {
+		return err
+	}
+
+	var existing models.Category
+	if err := h.DB.Where("slug = ?", req.Slug).First(&existing).Error; err != gorm.ErrRecordNotFound {
+		return c.JSON(http.StatusConflict, echo.Map{"error": "Category already exists"})
+	}
+
+	category := models.Category{Slug: req.Slug, Name: req.Name, Description: req.Description}
+	if err := h.DB.Create(&category).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to create category"})
+	}
+
+	return c.JSON(http.StatusCreated, category)
+}
+
+// PUT /api/admin/categories/:id
+func (h *Handler) AdminUpdateCategory(c echo.Context) error {
+	req, err := bindCategory(c)
+	if err != nil {
+		return err
+	}
+
+	var category models.Category
+	if err := h.DB.First(&category, "id = ?", c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "Category not found"})
+	}
+
+	if req.Slug != category.Slug {
+		var existing models.Category
+		if err := h.DB.Where("slug = ?", req.Slug).First(&existing).Error; err != gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusConflict, echo.Map{"error": "Category already exists"})
+		}
+	}
+
+	category.Slug = req.Slug
+	category.Name = req.Name
+	category.Description = req.Description
+	if err := h.DB.Save(&category).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to update category"})
+	}
+
+	return c.JSON(http.StatusOK, category)
+}
+
+// DELETE /api/admin/categories/:id
+//
+// Agents in the category become uncategorized.
+func (h *Handler) AdminDeleteCategory(c echo.Context) error {
+	var category models.Category
+	if err := h.DB.First(&category, "id = ?", c.Param("id")).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "Category not found"})
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Agent{}).Where("category_id = ?", category.ID).
+			UpdateColumn("category_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to delete category"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestGetAgentsTagFilter(t *testing.T) {
+	h := newTestHandler(t, &llm.EchoProvider{})
+	user := createTestUser(t, h.DB, "ivan")
+	tagged := createTestAgent(t, h.DB, models.Agent{Name: "Tagged", UserID: user.ID})
+	createTestAgent(t, h.DB, models.Agent{Name: "Untagged", UserID: user.ID})
+	if err := setAgentTags(h.DB, &tagged, []string{"golang"}); err != nil {
+		t.Fatalf("setAgentTags: %v", err)
+	}
+
+	c, rec := newTestContext(http.MethodGet, "/api/agents?tag=Golang", "", 0)
+	if err := h.GetAgents(c); err != nil {
+		t.Fatalf("GetAgents: %v", err)
+	}
+	var body struct {
+		Data []models.Agent `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if len(body.Data) != 1 || body.Data[0].ID != tagged.ID {
+		t.Errorf("?tag=Golang returned %+v, want only the tagged agent", body.Data)
+	}
+
+	tooMany := url.Values{}
+	for _, tag := range strings.Fields("a b c d e f g h i j k") {
+		tooMany.Add("tag", tag)
+	}
+	for _, query := range []string{"tag=" + url.QueryEscape("bad!tag"), tooMany.Encode()} {
+		c, _ := newTestContext(http.MethodGet, "/api/agents?"+query, "", 0)
+		err := h.GetAgents(c)
+		var he *echo.HTTPError
+		if !errors.As(err, &he) || he.Code != http.StatusBadRequest {
+			t.Errorf("?%s: error = %v, want 400", query, err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/prompt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ========== TEMPLATES ==========
+
+// GET /api/agents/:id/template
+//
+// Describes the variables the agent's input template expects so clients
+// can build an input form for it.
+func (h *Handler) GetAgentTemplate(c echo.Context) error {
+	agent, err := h.findAgent(c)
+	if err != nil {
+		return err
+	}
+
+	t, err := prompt.Parse(agent.InputTemplate)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, echo.Map{"error": "Agent input template is invalid: " + err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"template":  t.Source(),
+		"variables": nonNilVariables(t.Variables()),
+	})
+}
+
+// POST /api/templates/inspect
+//
+// Validates a template without saving it, for live feedback in editors.
+func (h *Handler) InspectTemplate(c echo.Context) error {
+	var req struct {
+		Template string `json:"template"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "Invalid request"})
+	}
+
+	t, err := prompt.Parse(req.Template)
+	if err != nil {
+		return c.JSON(http.StatusOK, echo.Map{"valid": false, "error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"valid":     true,
+		"variables": nonNilVariables(t.Variables()),
+	})
+}
+
+// nonNilVariables makes sure an empty list serializes as [] rather than null.
+func nonNilVariables(vars []prompt.Variable) []prompt.Variable {
+	if vars == nil {
+		return []prompt.Variable{}
+	}
+	return vars
+}
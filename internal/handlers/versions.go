@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/similar"
+	"ai-agent-hub/internal/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========== AGENT VERSIONS ==========
+
+// versionedAgentColumns are the columns a version snapshots and a rollback
+// restores; see models.AgentVersion.ApplyTo.
+var versionedAgentColumns = []string{"name", "description", "avatar", "system_prompt", "input_template", "personality"}
+
+// lockAgent takes the agent's row lock for the rest of tx. Writers of an
+// agent's version history take it first so that concurrent updates number
+// their versions one after the other.
+func lockAgent(tx *gorm.DB, agentID uint) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		First(&models.Agent{}, agentID).Error
+}
+
+// recordAgentVersion appends a snapshot of agent to its version history.
+func recordAgentVersion(tx *gorm.DB, agent models.Agent, userID uint, note string) error {
+	if err := lockAgent(tx, agent.ID); err != nil {
+		return err
+	}
+
+	var latest int
+	if err := tx.Model(&models.AgentVersion{}).Where("agent_id = ?", agent.ID).
+		Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+		return err
+	}
+
+	v := models.SnapshotAgent(agent)
+	v.Version = latest + 1
+	v.CreatedByID = userID
+	v.Note = note
+	return tx.Create(&v).Error
+}
+
+// ensureInitialVersion snapshots agents created before version history
+// existed, so their original content is not lost on the first update.
+func ensureInitialVersion(tx *gorm.DB, agent models.Agent) error {
+	if err := lockAgent(tx, agent.ID); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := tx.Model(&models.AgentVersion{}).Where("agent_id = ?", agent.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return recordAgentVersion(tx, agent, agent.UserID, "Initial snapshot")
+}
+
+// findMyAgent loads an agent owned by the current user.
+func (h *Handler) findMyAgent(c echo.Context) (models.Agent, error) {
+	var agent models.Agent
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return agent, echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+	}
+
+	if err := h.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&agent).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return agent, echo.NewHTTPError(http.StatusNotFound, "Agent not found")
+		}
+		return agent, echo.NewHTTPError(http.StatusInternalServerError, "Database error")
+	}
+	return agent, nil
+}
+
+func (h *Handler) findAgentVersion(agentID uint, version string) (models.AgentVersion, error) {
+	var v models.AgentVersion
+
+	n, err := strconv.Atoi(version)
+	if err != nil {
+		return v, echo.NewHTTPError(http.StatusBadRequest, "Invalid version")
+	}
+	if err := h.DB.Where("agent_id = ? AND version = ?", agentID, n).First(&v).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return v, echo.NewHTTPError(http.StatusNotFound, "Version not found")
+		}
+		return v, echo.NewHTTPError(http.StatusInternalServerError, "Database error")
+	}
+	return v, nil
+}
+
+var versionListSpec = utils.ListSpec{
+	Sorts: map[string]string{
+		"version":    "version",
+		"created_at": "created_at",
+	},
+	DefaultSort: "-version",
+	Filters: map[string]utils.Filter{
+		"created_by_id": {Column: "created_by_id", Kind: utils.FilterNumber},
+	},
+	DateRanges: map[string]string{
+		"created_at": "created_at",
+	},
+	Key:   "id",
+	Model: &models.AgentVersion{},
+}
+
+// GET /api/my/agents/:id/versions
+func (h *Handler) GetMyAgentVersions(c echo.Context) error {
+	agent, err := h.findMyAgent(c)
+	if err != nil {
+		return err
+	}
+	lq, err := utils.ParseListQuery(c, versionListSpec)
+	if err != nil {
+		return err
+	}
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		return db.Where("agent_id = ?", agent.ID).Scopes(lq.Where)
+	}
+
+	var total int64
+	if err := h.DB.Model(&models.AgentVersion{}).Scopes(scope).Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch versions"})
+	}
+
+	var versions []models.AgentVersion
+	if err := h.DB.Scopes(scope, lq.Paginate).Find(&versions).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to fetch versions"})
+	}
+
+	versions, page := utils.FinishPage(lq, versions)
+
+	resp := lq.Response(versions, page, total)
+	utils.SetLinkHeader(c, resp)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GET /api/my/agents/:id/versions/:version
+func (h *Handler) GetMyAgentVersion(c echo.Context) error {
+	agent, err := h.findMyAgent(c)
+	if err != nil {
+		return err
+	}
+
+	v, err := h.findAgentVersion(agent.ID, c.Param("version"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, v)
+}
+
+// GET /api/my/agents/:id/versions/diff?from=&to=
+//
+// "to" defaults to the latest version.
+func (h *Handler) DiffMyAgentVersions(c echo.Context) error {
+	agent, err := h.findMyAgent(c)
+	if err != nil {
+		return err
+	}
+
+	from, err := h.findAgentVersion(agent.ID, c.QueryParam("from"))
+	if err != nil {
+		return err
+	}
+
+	var to models.AgentVersion
+	if c.QueryParam("to") == "" {
+		if err := h.DB.Where("agent_id = ?", agent.ID).Order("version desc").First(&to).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Database error"})
+		}
+	} else if to, err = h.findAgentVersion(agent.ID, c.QueryParam("to")); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"from":    from.Version,
+		"to":      to.Version,
+		"changes": from.Diff(to),
+	})
+}
+
+// POST /api/my/agents/:id/versions/:version/rollback
+//
+// Rolling back restores the old content as a new version; history is never
+// rewritten.
+func (h *Handler) RollbackMyAgent(c echo.Context) error {
+	agent, err := h.findMyAgent(c)
+	if err != nil {
+		return err
+	}
+	userID, _ := currentUserID(c)
+
+	target, err := h.findAgentVersion(agent.ID, c.Param("version"))
+	if err != nil {
+		return err
+	}
+
+	target.ApplyTo(&agent)
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// Only the versioned fields are written, so counters changed since
+		// the agent was loaded are kept.
+		if err := tx.Model(&agent).Select(versionedAgentColumns).Updates(&agent).Error; err != nil {
+			return err
+		}
+		return recordAgentVersion(tx, agent, userID, fmt.Sprintf("Rolled back to version %d", target.Version))
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to roll back agent"})
+	}
+
+	similar.MarkDirty(agent.ID)
+	showShareSlug(c, &agent)
+	return c.JSON(http.StatusOK, agent)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/llm"
+	"ai-agent-hub/internal/models"
+	"net/http"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestRollbackMyAgentKeepsConcurrentCounters(t *testing.T) {
+	h := newTestHandler(t, &llm.EchoProvider{})
+	owner := createTestUser(t, h.DB, "owner")
+	fan := createTestUser(t, h.DB, "fan")
+	agent := createTestAgent(t, h.DB, models.Agent{Name: "Original", UserID: owner.ID})
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := recordAgentVersion(tx, agent, owner.ID, ""); err != nil {
+			return err
+		}
+		agent.Name = "Renamed"
+		if err := tx.Model(&agent).Update("name", agent.Name).Error; err != nil {
+			return err
+		}
+		return recordAgentVersion(tx, agent, owner.ID, "")
+	})
+	if err != nil {
+		t.Fatalf("record versions: %v", err)
+	}
+
+	// A fan likes the agent and an admin features it while the rollback runs.
+	afterAgentLoad(t, h.DB, func() {
+		likeTestAgent(t, h, agent.ID, fan.ID)
+		h.DB.Model(&models.Agent{}).Where("id = ?", agent.ID).UpdateColumn("is_featured", true)
+	})
+
+	c, rec := newTestContext(http.MethodPost, "/", "", owner.ID, "id", idParam(agent.ID), "version", "1")
+	if err := h.RollbackMyAgent(c); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("RollbackMyAgent: %v, status %d, body %s", err, rec.Code, rec.Body)
+	}
+
+	var after models.Agent
+	h.DB.First(&after, agent.ID)
+	if after.Name != "Original" {
+		t.Errorf("name = %q, want the rolled back %q", after.Name, "Original")
+	}
+	if after.LikeCount != 1 || !after.IsFeatured {
+		t.Errorf("likeCount = %d, isFeatured = %v; the rollback overwrote them", after.LikeCount, after.IsFeatured)
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"ai-agent-hub/internal/models"
+	"crypto/rand"
+	"encoding/base32"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ========== VISIBILITY ==========
+
+var shareSlugEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// publicAgents limits a query to agents anyone may discover. Every public
+// list, search and count of agents goes through it.
+func publicAgents(db *gorm.DB) *gorm.DB {
+	return db.Where("agents.visibility = ?", models.VisibilityPublic)
+}
+
+// newShareSlug returns 26 random characters (128 bits of entropy). That is
+// too long to ever parse as an agent ID, see findAgent.
+func newShareSlug() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToLower(shareSlugEncoding.EncodeToString(b)), nil
+}
+
+// setVisibility applies a requested visibility, minting a share slug when
+// the agent becomes unlisted and dropping it otherwise. An empty value
+// keeps the current visibility, or makes a new agent public.
+func setVisibility(agent *models.Agent, v string) error {
+	if v == "" {
+		v = agent.Visibility
+	}
+	if v == "" {
+		v = models.VisibilityPublic
+	}
+	if !models.ValidVisibility(v) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid visibility, expected private, unlisted or public")
+	}
+
+	agent.Visibility = v
+	if v != models.VisibilityUnlisted {
+		agent.ShareSlug = nil
+		return nil
+	}
+	if agent.ShareSlug == nil {
+		slug, err := newShareSlug()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create share link")
+		}
+		agent.ShareSlug = &slug
+	}
+	return nil
+}
+
+// showShareSlugs reveals the share slug of the agents the caller owns.
+// Everyone else only ever learns a slug by being given the link.
+func showShareSlugs(c echo.Context, agents []models.Agent) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	for i := range agents {
+		if agents[i].UserID == userID && agents[i].ShareSlug != nil {
+			agents[i].OwnerShareSlug = *agents[i].ShareSlug
+		}
+	}
+}
+
+func showShareSlug(c echo.Context, agent *models.Agent) {
+	agents := []models.Agent{*agent}
+	showShareSlugs(c, agents)
+	agent.OwnerShareSlug = agents[0].OwnerShareSlug
+}
+
+// findAgent loads the agent addressed by the :id param as the caller may
+// see it. The param is either an ID, which finds public agents and the
+// caller's own, or a share slug, which is the only way to reach someone
+// else's unlisted agent. Private agents are 404 to everyone but the owner.
+func (h *Handler) findAgent(c echo.Context, scopes ...func(*gorm.DB) *gorm.DB) (models.Agent, error) {
+	var agent models.Agent
+	ref := c.Param("id")
+
+	q := h.DB.Scopes(scopes...)
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		userID, _ := currentUserID(c)
+		q = q.Where("agents.id = ? AND (agents.visibility = ? OR agents.user_id = ?)", id, models.VisibilityPublic, userID)
+	} else {
+		q = q.Where("agents.share_slug = ? AND agents.visibility = ?", ref, models.VisibilityUnlisted)
+	}
+
+	if err := q.First(&agent).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return agent, echo.NewHTTPError(http.StatusNotFound, "Agent not found")
+		}
+		return agent, echo.NewHTTPError(http.StatusInternalServerError, "Database error")
+	}
+	return agent, nil
+}
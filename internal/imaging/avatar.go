@@ -0,0 +1,93 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// AvatarSizes are the square thumbnail edges every avatar is stored at.
+var AvatarSizes = []int{64, 256, 512}
+
+// maxPixels bounds decoded images so a small, highly compressed upload
+// can't exhaust memory: 16 MP is 64 MB as RGBA, and at most
+// maxConcurrentDecodes are decoded at a time.
+const (
+	maxPixels            = 16_000_000
+	maxConcurrentDecodes = 4
+)
+
+var decodeSlots = make(chan struct{}, maxConcurrentDecodes)
+
+var allowedTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ErrUnsupported is returned for uploads that aren't a supported image,
+// judged by their content rather than their file name or headers.
+var ErrUnsupported = errors.New("unsupported image type, use PNG, JPEG, GIF or WebP")
+
+// Avatar center-crops an uploaded image to a square and scales it to each
+// of AvatarSizes, returning PNG bytes keyed by size.
+func Avatar(data []byte) (map[int][]byte, error) {
+	if !allowedTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupported
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("image is too large (%dx%d)", cfg.Width, cfg.Height)
+	}
+
+	decodeSlots <- struct{}{}
+	defer func() { <-decodeSlots }()
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	src = squareCrop(src)
+
+	out := make(map[int][]byte, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, err
+		}
+		out[size] = buf.Bytes()
+	}
+	return out, nil
+}
+
+type subImager interface {
+	SubImage(r image.Rectangle) image.Image
+}
+
+func squareCrop(img image.Image) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+
+	if s, ok := img.(subImager); ok {
+		return s.SubImage(image.Rect(x, y, x+side, y+side))
+	}
+	return img
+}
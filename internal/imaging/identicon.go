@@ -0,0 +1,129 @@
+package imaging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/url"
+)
+
+const identiconGrid = 5
+
+// Identicon is a symmetric 5x5 pattern and colour derived from a seed. The
+// same seed always gives the same identicon.
+type Identicon struct {
+	Color color.NRGBA
+	Cells [identiconGrid][identiconGrid]bool
+}
+
+var identiconBackground = color.NRGBA{0xf0, 0xf0, 0xf0, 0xff}
+
+// IdenticonPath is where GET /api/avatars serves the identicon for seed.
+func IdenticonPath(seed string) string {
+	return "/api/avatars/" + url.PathEscape(seed) + ".svg"
+}
+
+func NewIdenticon(seed string) Identicon {
+	sum := sha256.Sum256([]byte(seed))
+
+	var ic Identicon
+	hue := float64(uint16(sum[0])<<8|uint16(sum[1])) / 65536 * 360
+	saturation := 0.45 + float64(sum[2])/255*0.2
+	lightness := 0.45 + float64(sum[3])/255*0.15
+	ic.Color = hsl(hue, saturation, lightness)
+
+	// Fill the left three columns from the hash and mirror them.
+	bit := 0
+	for x := 0; x < (identiconGrid+1)/2; x++ {
+		for y := 0; y < identiconGrid; y++ {
+			on := sum[4+bit/8]&(1<<(bit%8)) != 0
+			ic.Cells[y][x] = on
+			ic.Cells[y][identiconGrid-1-x] = on
+			bit++
+		}
+	}
+	return ic
+}
+
+// SVG renders the identicon with a half-cell margin.
+func (ic Identicon) SVG() []byte {
+	var b bytes.Buffer
+	fill := fmt.Sprintf("#%02x%02x%02x", ic.Color.R, ic.Color.G, ic.Color.B)
+	bg := fmt.Sprintf("#%02x%02x%02x", identiconBackground.R, identiconBackground.G, identiconBackground.B)
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 12 12" shape-rendering="crispEdges">`)
+	fmt.Fprintf(&b, `<rect width="12" height="12" fill="%s"/>`, bg)
+	for y, row := range ic.Cells {
+		for x, on := range row {
+			if on {
+				fmt.Fprintf(&b, `<rect x="%d" y="%d" width="2" height="2" fill="%s"/>`, 1+2*x, 1+2*y, fill)
+			}
+		}
+	}
+	b.WriteString(`</svg>`)
+	return b.Bytes()
+}
+
+// PNG renders the identicon as a size x size image.
+func (ic Identicon) PNG(size int) ([]byte, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	// Lay the grid out on 12 units (5 cells of 2 plus a unit of margin on
+	// each side) and round each edge so cells tile without gaps.
+	edge := func(unit int) int { return unit * size / 12 }
+
+	for py := 0; py < size; py++ {
+		for px := 0; px < size; px++ {
+			img.SetNRGBA(px, py, identiconBackground)
+		}
+	}
+	for y, row := range ic.Cells {
+		for x, on := range row {
+			if !on {
+				continue
+			}
+			for py := edge(1 + 2*y); py < edge(3+2*y); py++ {
+				for px := edge(1 + 2*x); px < edge(3+2*x); px++ {
+					img.SetNRGBA(px, py, ic.Color)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func hsl(h, s, l float64) color.NRGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return color.NRGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,82 @@
+package line
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const DefaultAPIBaseURL = "https://api.line.me"
+
+// maxTextLength is the Messaging API limit for a single text message.
+const maxTextLength = 5000
+
+// Client calls the LINE Messaging API for one channel.
+type Client struct {
+	BaseURL     string
+	AccessToken string
+	HTTP        *http.Client
+}
+
+// NewClient creates a client for a channel. An empty baseURL targets the
+// real LINE API; tests point it at a local stand-in server instead.
+func NewClient(baseURL, accessToken string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIBaseURL
+	}
+	return &Client{
+		BaseURL:     strings.TrimRight(baseURL, "/"),
+		AccessToken: accessToken,
+		HTTP:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type TextMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type replyRequest struct {
+	ReplyToken string        `json:"replyToken"`
+	Messages   []TextMessage `json:"messages"`
+}
+
+// ReplyText answers an event using its reply token.
+func (c *Client) ReplyText(ctx context.Context, replyToken, text string) error {
+	if utf8.RuneCountInString(text) > maxTextLength {
+		text = string([]rune(text)[:maxTextLength])
+	}
+
+	payload, err := json.Marshal(replyRequest{
+		ReplyToken: replyToken,
+		Messages:   []TextMessage{{Type: "text", Text: text}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/v2/bot/message/reply", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("line reply returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
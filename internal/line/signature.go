@@ -0,0 +1,28 @@
+package line
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the webhook body.
+const SignatureHeader = "X-Line-Signature"
+
+// Sign computes the signature LINE sends for body under channelSecret.
+func Sign(channelSecret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(channelSecret))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateSignature reports whether signature matches body.
+func ValidateSignature(channelSecret string, body []byte, signature string) bool {
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(channelSecret))
+	mac.Write(body)
+	return hmac.Equal(decoded, mac.Sum(nil))
+}
@@ -0,0 +1,48 @@
+package line
+
+import (
+	"encoding/json"
+)
+
+// Only the parts of the webhook payload the hub uses are modelled here. See
+// https://developers.line.biz/en/reference/messaging-api/#webhook-event-objects
+
+type WebhookRequest struct {
+	Destination string  `json:"destination"`
+	Events      []Event `json:"events"`
+}
+
+type Event struct {
+	Type       string        `json:"type"`
+	ReplyToken string        `json:"replyToken"`
+	Timestamp  int64         `json:"timestamp"`
+	Source     EventSource   `json:"source"`
+	Message    *EventMessage `json:"message,omitempty"`
+}
+
+type EventSource struct {
+	Type    string `json:"type"`
+	UserID  string `json:"userId"`
+	GroupID string `json:"groupId,omitempty"`
+	RoomID  string `json:"roomId,omitempty"`
+}
+
+type EventMessage struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ParseWebhook decodes a webhook body. The signature must be checked first.
+func ParseWebhook(body []byte) (*WebhookRequest, error) {
+	var req WebhookRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// IsTextMessage reports whether the event is a text message from a user.
+func (e Event) IsTextMessage() bool {
+	return e.Type == "message" && e.Message != nil && e.Message.Type == "text" && e.Source.UserID != ""
+}
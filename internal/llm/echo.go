@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// EchoProvider is a deterministic provider for local runs and tests.
+// It never calls out of the process and always answers the same way
+// for the same request.
+type EchoProvider struct{}
+
+func NewEchoProvider() *EchoProvider {
+	return &EchoProvider{}
+}
+
+func (p *EchoProvider) Name() string {
+	return "echo"
+}
+
+func (p *EchoProvider) Complete(ctx context.Context, req Request) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+	return Response{Content: echoReply(req), Model: "echo"}, nil
+}
+
+func echoReply(req Request) string {
+	var system, last string
+	for _, m := range req.Messages {
+		switch m.Role {
+		case RoleSystem:
+			system = m.Content
+		case RoleUser:
+			last = m.Content
+		}
+	}
+
+	// Only the first line of the system prompt is echoed to keep replies short.
+	if i := strings.IndexByte(system, '\n'); i >= 0 {
+		system = system[:i]
+	}
+	if system == "" {
+		return fmt.Sprintf("Echo: %s", last)
+	}
+	return fmt.Sprintf("[%s] Echo: %s", system, last)
+}
+
+// Stream emits the echo reply one word at a time.
+func (p *EchoProvider) Stream(ctx context.Context, req Request, fn StreamFunc) (Response, error) {
+	reply := echoReply(req)
+	words := strings.SplitAfter(reply, " ")
+	for _, w := range words {
+		if err := ctx.Err(); err != nil {
+			return Response{}, err
+		}
+		if err := fn(w); err != nil {
+			return Response{}, err
+		}
+	}
+	return Response{Content: reply, Model: "echo"}, nil
+}
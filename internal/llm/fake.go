@@ -0,0 +1,49 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// FakeProvider replays a fixed list of chunks. It is meant for tests that
+// need to exercise streaming, slow providers or mid-stream failures.
+type FakeProvider struct {
+	Chunks []string
+	// Delay is waited before each chunk is emitted.
+	Delay time.Duration
+	// Err, when set, is returned after all chunks have been emitted.
+	Err error
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) Complete(ctx context.Context, req Request) (Response, error) {
+	return p.Stream(ctx, req, func(string) error { return nil })
+}
+
+func (p *FakeProvider) Stream(ctx context.Context, req Request, fn StreamFunc) (Response, error) {
+	var out strings.Builder
+	for _, chunk := range p.Chunks {
+		if p.Delay > 0 {
+			select {
+			case <-ctx.Done():
+				return Response{}, ctx.Err()
+			case <-time.After(p.Delay):
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return Response{}, err
+		}
+		if err := fn(chunk); err != nil {
+			return Response{}, err
+		}
+		out.WriteString(chunk)
+	}
+	if p.Err != nil {
+		return Response{}, p.Err
+	}
+	return Response{Content: out.String(), Model: "fake"}, nil
+}
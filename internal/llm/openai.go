@@ -0,0 +1,206 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultOpenAIURL = "https://api.openai.com/v1"
+
+// A completion, and a stream above all, may legitimately take minutes, so
+// the response body is never timed out as a whole; the caller's context
+// bounds the call. Only waits that mean the provider is stuck are bounded.
+const (
+	// openAIHeaderTimeout bounds the wait for the response headers, after
+	// the request is sent.
+	openAIHeaderTimeout = 60 * time.Second
+	// defaultStreamIdleTimeout aborts a stream that stops sending chunks.
+	defaultStreamIdleTimeout = 60 * time.Second
+)
+
+var errStreamStalled = errors.New("llm stream stalled")
+
+// OpenAIProvider talks to any OpenAI-compatible chat completions API.
+type OpenAIProvider struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	Client  *http.Client
+	// StreamIdleTimeout is the longest wait for the next streamed chunk;
+	// zero means defaultStreamIdleTimeout.
+	StreamIdleTimeout time.Duration
+}
+
+// newOpenAIClient bounds connection setup (by the default transport's dial
+// and TLS handshake timeouts) and time to first byte, but not the body.
+func newOpenAIClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = openAIHeaderTimeout
+	return &http.Client{Transport: transport}
+}
+
+func NewOpenAIProvider(baseURL, apiKey, model string) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = defaultOpenAIURL
+	}
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+	return &OpenAIProvider{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		Model:   model,
+		Client:  newOpenAIClient(),
+	}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+type openAIChatRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Stream   bool      `json:"stream,omitempty"`
+}
+
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req Request) (Response, error) {
+	model := req.Model
+	if model == "" {
+		model = p.Model
+	}
+
+	resp, err := p.post(ctx, openAIChatRequest{Model: model, Messages: req.Messages})
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var out openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return Response{}, fmt.Errorf("decode completion: %w", err)
+	}
+	if len(out.Choices) == 0 {
+		return Response{}, fmt.Errorf("completion returned no choices")
+	}
+
+	return Response{Content: out.Choices[0].Message.Content, Model: out.Model}, nil
+}
+
+type openAIStreamChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+func (p *OpenAIProvider) Stream(ctx context.Context, req Request, fn StreamFunc) (Response, error) {
+	model := req.Model
+	if model == "" {
+		model = p.Model
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	idleTimeout := p.StreamIdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultStreamIdleTimeout
+	}
+	idle := time.AfterFunc(idleTimeout, func() { cancel(errStreamStalled) })
+	defer idle.Stop()
+
+	resp, err := p.post(ctx, openAIChatRequest{Model: model, Messages: req.Messages, Stream: true})
+	if err != nil {
+		return Response{}, streamErr(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	var out strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		idle.Reset(idleTimeout)
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return Response{}, fmt.Errorf("decode stream chunk: %w", err)
+		}
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if err := fn(choice.Delta.Content); err != nil {
+				return Response{}, err
+			}
+			out.WriteString(choice.Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Response{}, streamErr(ctx, err)
+	}
+
+	return Response{Content: out.String(), Model: model}, nil
+}
+
+// streamErr reports a stall as such rather than as the read error it
+// caused.
+func streamErr(ctx context.Context, err error) error {
+	if errors.Is(context.Cause(ctx), errStreamStalled) {
+		return errStreamStalled
+	}
+	return err
+}
+
+func (p *OpenAIProvider) post(ctx context.Context, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	resp, err := p.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("llm provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newStreamServer streams one chunk per text, waiting the given delay
+// before each, and then hangs for stall before finishing.
+func newStreamServer(t *testing.T, texts []string, delay, stall time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for _, text := range texts {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+			fmt.Fprintf(w, "data: {\"model\":\"m\",\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", text)
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-time.After(stall):
+		case <-r.Context().Done():
+			return
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOpenAIStreamOutlastsIdleTimeout(t *testing.T) {
+	srv := newStreamServer(t, []string{"a", "b", "c", "d", "e", "f"}, 40*time.Millisecond, 0)
+	p := NewOpenAIProvider(srv.URL, "", "")
+	p.StreamIdleTimeout = 100 * time.Millisecond
+
+	// The whole stream takes more than twice the idle timeout.
+	resp, err := p.Stream(context.Background(), Request{}, func(string) error { return nil })
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if resp.Content != "abcdef" {
+		t.Errorf("content = %q, want abcdef", resp.Content)
+	}
+}
+
+func TestOpenAIStreamAbortsWhenStalled(t *testing.T) {
+	srv := newStreamServer(t, []string{"a"}, 0, 5*time.Second)
+	p := NewOpenAIProvider(srv.URL, "", "")
+	p.StreamIdleTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	var got string
+	_, err := p.Stream(context.Background(), Request{}, func(s string) error { got += s; return nil })
+	if !errors.Is(err, errStreamStalled) {
+		t.Fatalf("Stream error = %v, want errStreamStalled", err)
+	}
+	if got != "a" {
+		t.Errorf("chunks before the stall = %q, want a", got)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("stalled stream took %v to abort", d)
+	}
+}
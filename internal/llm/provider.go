@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// Roles used in chat messages sent to a provider.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type Request struct {
+	Model    string    `json:"model,omitempty"`
+	Messages []Message `json:"messages"`
+}
+
+type Response struct {
+	Content string `json:"content"`
+	Model   string `json:"model"`
+}
+
+// Provider is implemented by every LLM backend an agent can talk to.
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req Request) (Response, error)
+}
+
+// NewProviderFromEnv picks a provider based on LLM_PROVIDER, "echo" or
+// "openai". Unknown values are an error rather than a silent fallback. Only
+// outside production (ENV != "production") does an unset LLM_PROVIDER fall
+// back to the in-process echo provider.
+func NewProviderFromEnv() (Provider, error) {
+	name := os.Getenv("LLM_PROVIDER")
+	if name == "" {
+		if os.Getenv("ENV") == "production" {
+			return nil, errors.New("LLM_PROVIDER is not set; set it to openai")
+		}
+		log.Printf("LLM_PROVIDER is not set, answering with the echo provider (development only)")
+		name = "echo"
+	}
+
+	switch name {
+	case "echo":
+		return NewEchoProvider(), nil
+	case "openai":
+		return NewOpenAIProvider(
+			os.Getenv("LLM_API_URL"),
+			os.Getenv("LLM_API_KEY"),
+			os.Getenv("LLM_MODEL"),
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q, expected echo or openai", name)
+	}
+}
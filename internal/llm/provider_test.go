@@ -0,0 +1,36 @@
+package llm
+
+import (
+	"testing"
+)
+
+func TestNewProviderFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{"unset in development", map[string]string{}, "echo", false},
+		{"unset in production", map[string]string{"ENV": "production"}, "", true},
+		{"echo opted into", map[string]string{"ENV": "production", "LLM_PROVIDER": "echo"}, "echo", false},
+		{"openai", map[string]string{"ENV": "production", "LLM_PROVIDER": "openai"}, "openai", false},
+		{"misspelled", map[string]string{"LLM_PROVIDER": "opneai"}, "", true},
+		{"unknown in production", map[string]string{"ENV": "production", "LLM_PROVIDER": "anthropic"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"ENV", "LLM_PROVIDER", "LLM_API_URL", "LLM_API_KEY", "LLM_MODEL"} {
+				t.Setenv(k, tt.env[k])
+			}
+
+			got, err := NewProviderFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewProviderFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Name() != tt.want {
+				t.Errorf("provider = %q, want %q", got.Name(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package llm
+
+import "context"
+
+// StreamFunc receives each chunk of a reply as it is generated. Returning
+// an error aborts the stream.
+type StreamFunc func(delta string) error
+
+// StreamingProvider is implemented by providers that can emit a reply
+// incrementally instead of all at once.
+type StreamingProvider interface {
+	Provider
+	Stream(ctx context.Context, req Request, fn StreamFunc) (Response, error)
+}
+
+// Stream streams a reply from p. Providers without native streaming
+// support are completed in one call and delivered as a single chunk.
+func Stream(ctx context.Context, p Provider, req Request, fn StreamFunc) (Response, error) {
+	if sp, ok := p.(StreamingProvider); ok {
+		return sp.Stream(ctx, req, fn)
+	}
+
+	resp, err := p.Complete(ctx, req)
+	if err != nil {
+		return Response{}, err
+	}
+	if err := fn(resp.Content); err != nil {
+		return Response{}, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,68 @@
+// Package mail delivers transactional email through a database outbox.
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers a single email.
+type Mailer interface {
+	Send(ctx context.Context, e Email) error
+}
+
+// NewMailerFromEnv picks a mailer based on MAIL_DRIVER:
+//
+//	smtp  SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, MAIL_FROM
+//	file  appends every email to MAIL_FILE (default mail.log)
+//	log   prints every email to the server log
+//
+// The file and log sinks write verification and password reset links in
+// the clear, so they have to be chosen explicitly. Only outside production
+// (ENV != "production") does an unset MAIL_DRIVER fall back to log.
+func NewMailerFromEnv() (Mailer, error) {
+	driver := os.Getenv("MAIL_DRIVER")
+	if driver == "" {
+		if os.Getenv("ENV") == "production" {
+			return nil, errors.New("MAIL_DRIVER is not set; set it to smtp to send email")
+		}
+		log.Printf("MAIL_DRIVER is not set, printing emails to the log (development only)")
+		driver = "log"
+	}
+
+	switch driver {
+	case "smtp":
+		port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		m := &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}
+		if m.Host == "" || m.From == "" {
+			return nil, errors.New("MAIL_DRIVER=smtp needs SMTP_HOST and MAIL_FROM")
+		}
+		return m, nil
+	case "file":
+		path := os.Getenv("MAIL_FILE")
+		if path == "" {
+			path = "mail.log"
+		}
+		return &FileMailer{Path: path}, nil
+	case "log":
+		return &LogMailer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q, expected smtp, file or log", driver)
+	}
+}
@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"testing"
+)
+
+func TestNewMailerFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Mailer
+		wantErr bool
+	}{
+		{"unset in development", map[string]string{}, &LogMailer{}, false},
+		{"unset in production", map[string]string{"ENV": "production"}, nil, true},
+		{"log opted into", map[string]string{"ENV": "production", "MAIL_DRIVER": "log"}, &LogMailer{}, false},
+		{"file", map[string]string{"MAIL_DRIVER": "file"}, &FileMailer{Path: "mail.log"}, false},
+		{"unknown driver", map[string]string{"MAIL_DRIVER": "sendgrid"}, nil, true},
+		{"smtp without host", map[string]string{"MAIL_DRIVER": "smtp", "MAIL_FROM": "hub@example.com"}, nil, true},
+		{
+			"smtp",
+			map[string]string{"MAIL_DRIVER": "smtp", "SMTP_HOST": "mail.example.com", "SMTP_PORT": "2525", "MAIL_FROM": "hub@example.com"},
+			&SMTPMailer{Host: "mail.example.com", Port: 2525, From: "hub@example.com"},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"ENV", "MAIL_DRIVER", "MAIL_FILE", "SMTP_HOST", "SMTP_PORT", "SMTP_USERNAME", "SMTP_PASSWORD", "MAIL_FROM"} {
+				t.Setenv(k, tt.env[k])
+			}
+
+			got, err := NewMailerFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewMailerFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			switch want := tt.want.(type) {
+			case *SMTPMailer:
+				if m, ok := got.(*SMTPMailer); !ok || *m != *want {
+					t.Errorf("got %#v, want %#v", got, want)
+				}
+			case *FileMailer:
+				if m, ok := got.(*FileMailer); !ok || m.Path != want.Path {
+					t.Errorf("got %#v, want %#v", got, want)
+				}
+			case *LogMailer:
+				if _, ok := got.(*LogMailer); !ok {
+					t.Errorf("got %#v, want a LogMailer", got)
+				}
+			}
+		})
+	}
+}
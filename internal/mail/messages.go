@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// appURL builds a link into the frontend from APP_URL.
+func appURL(path, token string) string {
+	base := strings.TrimRight(os.Getenv("APP_URL"), "/")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return fmt.Sprintf("%s%s?token=%s", base, path, url.QueryEscape(token))
+}
+
+func VerificationEmail(to, username, token string) Email {
+	return Email{
+		To:      to,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(`Hi %s,
+
+Please confirm your email address by opening the link below:
+
+%s
+
+If you did not create an account, you can ignore this email.`, username, appURL("/verify-email", token)),
+	}
+}
+
+func PasswordResetEmail(to, username, token string) Email {
+	return Email{
+		To:      to,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(`Hi %s,
+
+Someone asked to reset the password for your account. Open the link below
+to choose a new one. The link expires in one hour and can be used once.
+
+%s
+
+If this wasn't you, you can ignore this email; your password is unchanged.`, username, appURL("/reset-password", token)),
+	}
+}
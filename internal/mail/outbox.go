@@ -0,0 +1,128 @@
+package mail
+
+import (
+	"ai-agent-hub/internal/models"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxAttempts  = 8
+	batchSize    = 20
+	pollInterval = 5 * time.Second
+
+	// sendTimeout bounds a single delivery attempt.
+	sendTimeout = 30 * time.Second
+	// leaseDuration covers sending a whole batch, so a live dispatcher never
+	// has its emails taken over by another one.
+	leaseDuration = batchSize*sendTimeout + time.Minute
+)
+
+// Enqueue stores an email in the outbox. Pass the transaction that makes
+// the triggering change so the email is only sent if it commits.
+func Enqueue(db *gorm.DB, e Email) error {
+	return db.Create(&models.OutboxEmail{
+		To:            e.To,
+		Subject:       e.Subject,
+		Body:          e.Body,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// Dispatcher sends pending outbox emails in the background.
+type Dispatcher struct {
+	DB     *gorm.DB
+	Mailer Mailer
+}
+
+func NewDispatcher(db *gorm.DB, mailer Mailer) *Dispatcher {
+	return &Dispatcher{DB: db, Mailer: mailer}
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchOnce(ctx); err != nil {
+			log.Printf("mail outbox: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce sends one batch of due emails and returns how many were sent.
+//
+// The batch is claimed by leasing its rows in a short transaction, using
+// SKIP LOCKED so several instances can run side by side. Sending happens
+// outside of any transaction, so a slow mail server never holds a database
+// connection or row locks. An instance that dies mid-batch leaves its lease
+// to expire, after which the email is retried; delivery is at least once.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	batch, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, msg := range batch {
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := d.Mailer.Send(sendCtx, Email{To: msg.To, Subject: msg.Subject, Body: msg.Body})
+		cancel()
+
+		updates := map[string]any{"attempts": msg.Attempts + 1, "leased_until": nil}
+		if err != nil {
+			// Back off quadratically: 1, 4, 9, ... minutes.
+			backoff := time.Duration((msg.Attempts+1)*(msg.Attempts+1)) * time.Minute
+			updates["last_error"] = err.Error()
+			updates["next_attempt_at"] = time.Now().Add(backoff)
+		} else {
+			updates["sent_at"] = time.Now()
+			updates["last_error"] = ""
+			sent++
+		}
+
+		// Record the outcome even if ctx was cancelled during the send.
+		if err := d.DB.Model(&models.OutboxEmail{}).Where("id = ?", msg.ID).Updates(updates).Error; err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// claim leases a batch of due emails to this dispatcher.
+func (d *Dispatcher) claim(ctx context.Context) ([]models.OutboxEmail, error) {
+	var batch []models.OutboxEmail
+
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NULL AND attempts < ? AND next_attempt_at <= ?", maxAttempts, now).
+			Where("(leased_until IS NULL OR leased_until <= ?)", now).
+			Order("id").Limit(batchSize).Find(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(batch))
+		for i, msg := range batch {
+			ids[i] = msg.ID
+		}
+		return tx.Model(&models.OutboxEmail{}).Where("id IN ?", ids).
+			Update("leased_until", now.Add(leaseDuration)).Error
+	})
+
+	return batch, err
+}
@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"ai-agent-hub/internal/models"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.OutboxEmail{}); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
+
+// funcMailer sends through a function.
+type funcMailer func(ctx context.Context, e Email) error
+
+func (f funcMailer) Send(ctx context.Context, e Email) error {
+	return f(ctx, e)
+}
+
+func TestDispatchOnceSendsOutsideTransactionUnderLease(t *testing.T) {
+	db := newTestDB(t)
+	if err := Enqueue(db, Email{To: "a@example.com", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	var during int
+	var sendErr error
+	mailer := funcMailer(func(ctx context.Context, e Email) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("Send called without a deadline")
+		}
+		// Another dispatcher running while this one sends must neither be
+		// blocked by it nor send the same email.
+		during, sendErr = NewDispatcher(db, funcMailer(func(context.Context, Email) error {
+			t.Error("leased email sent twice")
+			return nil
+		})).DispatchOnce(ctx)
+		return nil
+	})
+
+	sent, err := NewDispatcher(db, mailer).DispatchOnce(context.Background())
+	if err != nil || sent != 1 {
+		t.Fatalf("DispatchOnce = %d, %v; want 1 sent", sent, err)
+	}
+	if sendErr != nil || during != 0 {
+		t.Errorf("concurrent DispatchOnce = %d, %v; want 0 sent", during, sendErr)
+	}
+
+	var msg models.OutboxEmail
+	db.First(&msg)
+	if msg.SentAt == nil || msg.Attempts != 1 || msg.LeasedUntil != nil {
+		t.Errorf("after send: %+v", msg)
+	}
+}
+
+func TestDispatchOnceRecordsFailureAndReleasesLease(t *testing.T) {
+	db := newTestDB(t)
+	Enqueue(db, Email{To: "a@example.com", Subject: "Hi", Body: "Hello"})
+
+	d := NewDispatcher(db, funcMailer(func(context.Context, Email) error {
+		return errors.New("connection refused")
+	}))
+	if sent, err := d.DispatchOnce(context.Background()); err != nil || sent != 0 {
+		t.Fatalf("DispatchOnce = %d, %v", sent, err)
+	}
+
+	var msg models.OutboxEmail
+	db.First(&msg)
+	if msg.SentAt != nil || msg.Attempts != 1 || msg.LastError != "connection refused" || msg.LeasedUntil != nil {
+		t.Errorf("after failure: %+v", msg)
+	}
+	if !msg.NextAttemptAt.After(time.Now()) {
+		t.Errorf("next attempt at %v, want a backoff", msg.NextAttemptAt)
+	}
+}
+
+func TestDispatchOnceTakesOverExpiredLease(t *testing.T) {
+	db := newTestDB(t)
+	Enqueue(db, Email{To: "a@example.com", Subject: "Hi", Body: "Hello"})
+	Enqueue(db, Email{To: "b@example.com", Subject: "Hi", Body: "Hello"})
+
+	// a@ was claimed by a dispatcher that died; b@ is still being sent.
+	db.Model(&models.OutboxEmail{}).Where("\"to\" = ?", "a@example.com").Update("leased_until", time.Now().Add(-time.Second))
+	db.Model(&models.OutboxEmail{}).Where("\"to\" = ?", "b@example.com").Update("leased_until", time.Now().Add(time.Minute))
+
+	var got []string
+	d := NewDispatcher(db, funcMailer(func(_ context.Context, e Email) error {
+		got = append(got, e.To)
+		return nil
+	}))
+	if _, err := d.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+	if len(got) != 1 || got[0] != "a@example.com" {
+		t.Errorf("sent to %v, want only the expired lease", got)
+	}
+}
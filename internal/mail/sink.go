@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer prints emails to the server log instead of sending them.
+type LogMailer struct{}
+
+func (m *LogMailer) Send(ctx context.Context, e Email) error {
+	log.Printf("📧 To: %s | Subject: %s\n%s", e.To, e.Subject, e.Body)
+	return nil
+}
+
+// FileMailer appends emails to a local file, which is handy for clicking
+// verification links during development.
+type FileMailer struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (m *FileMailer) Send(ctx context.Context, e Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n----------\n\n",
+		time.Now().Format(time.RFC3339), e.To, e.Subject, e.Body)
+	return err
+}
@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSMTPMailerGivesUpOnSilentServer(t *testing.T) {
+	// A server that accepts connections but never sends its greeting.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	m := &SMTPMailer{Host: "127.0.0.1", Port: addr.Port, From: "hub@example.com"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = m.Send(ctx, Email{To: "a@example.com", Subject: "Hi", Body: "Hello"})
+	if err == nil {
+		t.Fatal("Send succeeded against a silent server")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send took %v to give up", elapsed)
+	}
+}
@@ -0,0 +1,204 @@
+// Package manifest defines the portable file format agents are exported to
+// and imported from, so definitions can move between environments and live
+// in version control. A manifest looks like:
+//
+//	apiVersion: aihub/v1
+//	kind: Agent
+//	metadata:
+//	  name: Python Tutor
+//	  description: Patient help with Python
+//	  category: education
+//	  tags: [python, programming]
+//	spec:
+//	  systemPrompt: You are a patient Python tutor.
+//	  inputTemplate: "{{question}}"
+//	  personality: friendly
+package manifest
+
+import (
+	"ai-agent-hub/internal/models"
+	"ai-agent-hub/internal/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// APIVersion is the current format. Bump it for incompatible changes
+	// and keep decoding older versions.
+	APIVersion = "aihub/v1"
+	Kind       = "Agent"
+)
+
+type Format string
+
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+)
+
+// ParseFormat accepts a format name or file extension.
+func ParseFormat(s string) (Format, bool) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "json":
+		return JSON, true
+	case "yaml", "yml":
+		return YAML, true
+	}
+	return "", false
+}
+
+// Ext is the file extension exports use, e.g. "tutor.agent.yaml".
+func (f Format) Ext() string {
+	return ".agent." + string(f)
+}
+
+func (f Format) ContentType() string {
+	if f == YAML {
+		return "application/yaml"
+	}
+	return "application/json"
+}
+
+type Manifest struct {
+	APIVersion string   `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string   `json:"kind" yaml:"kind"`
+	Metadata   Metadata `json:"metadata" yaml:"metadata"`
+	Spec       Spec     `json:"spec" yaml:"spec"`
+}
+
+type Metadata struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Category    string   `json:"category,omitempty" yaml:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Avatar      string   `json:"avatar,omitempty" yaml:"avatar,omitempty"`
+	// ExportedAt is informational and ignored on import.
+	ExportedAt *time.Time `json:"exportedAt,omitempty" yaml:"exportedAt,omitempty"`
+}
+
+type Spec struct {
+	SystemPrompt  string `json:"systemPrompt" yaml:"systemPrompt"`
+	InputTemplate string `json:"inputTemplate,omitempty" yaml:"inputTemplate,omitempty"`
+	Personality   string `json:"personality,omitempty" yaml:"personality,omitempty"`
+	AllowForks    *bool  `json:"allowForks,omitempty" yaml:"allowForks,omitempty"`
+	// Visibility is private, unlisted or public. Omitted, new agents are
+	// public and existing ones keep theirs.
+	Visibility string `json:"visibility,omitempty" yaml:"visibility,omitempty"`
+}
+
+// FromAgent builds the manifest for an agent; its Category and Tags must
+// be preloaded to be included.
+func FromAgent(agent models.Agent, exportedAt time.Time) Manifest {
+	m := Manifest{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Metadata: Metadata{
+			Name:        agent.Name,
+			Description: agent.Description,
+			Avatar:      agent.Avatar,
+			ExportedAt:  &exportedAt,
+		},
+		Spec: Spec{
+			SystemPrompt:  agent.SystemPrompt,
+			InputTemplate: agent.InputTemplate,
+			Personality:   agent.Personality,
+			AllowForks:    agent.AllowForks,
+			Visibility:    agent.Visibility,
+		},
+	}
+	if agent.Category != nil {
+		m.Metadata.Category = agent.Category.Slug
+	}
+	for _, t := range agent.Tags {
+		m.Metadata.Tags = append(m.Metadata.Tags, t.Name)
+	}
+	return m
+}
+
+// Encode renders one manifest.
+func Encode(m Manifest, f Format) ([]byte, error) {
+	if f == YAML {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(m); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), enc.Close()
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Decode reads every manifest in data: a JSON object or array of objects,
+// or one or more YAML documents separated by "---". Unknown fields are
+// rejected so typos don't silently drop settings.
+func Decode(data []byte, f Format) ([]Manifest, error) {
+	var out []Manifest
+
+	if f == JSON {
+		trimmed := bytes.TrimSpace(data)
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.DisallowUnknownFields()
+
+		var err error
+		if bytes.HasPrefix(trimmed, []byte("[")) {
+			err = dec.Decode(&out)
+		} else {
+			var m Manifest
+			err = dec.Decode(&m)
+			out = append(out, m)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return out, nil
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	for {
+		var m Manifest
+		err := dec.Decode(&m)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// Validate checks what can be checked without a database. Categories,
+// tags and input templates are checked by the importer.
+func (m Manifest) Validate() error {
+	switch {
+	case m.APIVersion != APIVersion:
+		return fmt.Errorf("unsupported apiVersion %q, expected %q", m.APIVersion, APIVersion)
+	case m.Kind != Kind:
+		return fmt.Errorf("unsupported kind %q, expected %q", m.Kind, Kind)
+	case strings.TrimSpace(m.Metadata.Name) == "":
+		return errors.New("metadata.name is required")
+	case len([]rune(m.Metadata.Name)) > 100:
+		return errors.New("metadata.name must be at most 100 characters")
+	case strings.TrimSpace(m.Spec.SystemPrompt) == "":
+		return errors.New("spec.systemPrompt is required")
+	case m.Spec.Visibility != "" && !models.ValidVisibility(m.Spec.Visibility):
+		return fmt.Errorf("invalid spec.visibility %q, expected private, unlisted or public", m.Spec.Visibility)
+	case m.Metadata.Avatar != "" && !utils.ValidAvatarURL(m.Metadata.Avatar):
+		return errors.New("metadata.avatar must be an http or https URL")
+	}
+	return nil
+}
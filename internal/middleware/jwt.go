@@ -1,33 +1,75 @@
 package middleware
 
 import (
+	"ai-agent-hub/internal/auth"
+	"net/http"
 	"os"
 
+	"github.com/golang-jwt/jwt/v5"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
-// func GenerateJWT(secretKey string, userID string) (string, error) {
-// 	claims := jwt.MapClaims{
-// 		"sub": userID,
-// 		"iat": time.Now().Unix(),
-// 		"exp": time.Now().Add(time.Hour * 24).Unix(),
-// 	}
-
-// 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-// 	return token.SignedString([]byte(secretKey))
-// }
-
-// func ValidateJWT(tokenString string, secretKey string) (*jwt.Token, error) {
-// 	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-// 		return []byte(secretKey), nil
-// 	})
-// }
-
-func JWTMiddleware() echo.MiddlewareFunc {
+// JWTMiddleware validates the bearer token and rejects tokens whose login
+// session has been revoked (logout, refresh token reuse, password change).
+func JWTMiddleware(db *gorm.DB) echo.MiddlewareFunc {
     secret := os.Getenv("JWT_SECRET")
-    return echojwt.WithConfig(echojwt.Config{
+    validate := echojwt.WithConfig(echojwt.Config{
         SigningKey: []byte(secret),
     })
+
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return validate(func(c echo.Context) error {
+            if err := checkSession(c, db); err != nil {
+                return err
+            }
+            return next(c)
+        })
+    }
 }
 
+func checkSession(c echo.Context, db *gorm.DB) error {
+    token, ok := c.Get("user").(*jwt.Token)
+    if !ok {
+        return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+    }
+    claims, ok := token.Claims.(jwt.MapClaims)
+    if !ok {
+        return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+    }
+
+    // Tokens issued before sessions existed carry no sid and cannot be
+    // revoked, so they are no longer accepted.
+    sid, _ := claims["sid"].(string)
+    if sid == "" {
+        return echo.NewHTTPError(http.StatusUnauthorized, "Session expired")
+    }
+
+    active, err := auth.SessionActive(db, sid)
+    if err != nil {
+        return echo.NewHTTPError(http.StatusInternalServerError, "Database error")
+    }
+    if !active {
+        return echo.NewHTTPError(http.StatusUnauthorized, "Session has been revoked")
+    }
+    return nil
+}
+
+// OptionalJWTMiddleware authenticates the request when it carries a bearer
+// token and lets anonymous requests through untouched. It is used on public
+// routes whose responses are personalised for signed-in users.
+func OptionalJWTMiddleware(db *gorm.DB) echo.MiddlewareFunc {
+    required := JWTMiddleware(db)
+
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        authenticated := required(next)
+
+        return func(c echo.Context) error {
+            if c.Request().Header.Get(echo.HeaderAuthorization) == "" {
+                return next(c)
+            }
+            return authenticated(c)
+        }
+    }
+}
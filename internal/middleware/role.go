@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"ai-agent-hub/internal/models"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// RequireRole only lets through users whose token carries one of roles.
+// It must run after JWTMiddleware.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		allowed[r] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !allowed[RoleOf(c)] {
+				return echo.NewHTTPError(http.StatusForbidden, "Insufficient permissions")
+			}
+			return next(c)
+		}
+	}
+}
+
+// RoleOf returns the role in the request's token. Tokens issued before
+// roles existed count as regular users.
+func RoleOf(c echo.Context) string {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return ""
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	role, _ := claims["role"].(string)
+	if role == "" {
+		return models.RoleUser
+	}
+	return role
+}
@@ -4,6 +4,20 @@ import (
 	"gorm.io/gorm"
 )
 
+// Who can see an agent. Public agents are listed and searchable; unlisted
+// ones can only be opened through their share slug; private ones only by
+// their owner.
+const (
+	VisibilityPrivate  = "private"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPublic   = "public"
+)
+
+// ValidVisibility reports whether v is one of the known visibilities.
+func ValidVisibility(v string) bool {
+	return v == VisibilityPrivate || v == VisibilityUnlisted || v == VisibilityPublic
+}
+
 type Agent struct {
 	gorm.Model
 	Name          string `json:"name"`
@@ -15,4 +29,41 @@ type Agent struct {
 	UserID        uint   `json:"userId"`
 	IsFeatured    bool   `json:"isFeatured"`
 	ViewCount     uint   `json:"viewCount"`
+	LikeCount     uint   `json:"likeCount"`
+
+	Visibility string `json:"visibility" gorm:"size:16;not null;default:public;index"`
+	// ShareSlug is set only while the agent is unlisted and is replaced each
+	// time it becomes unlisted again, which revokes old links. It is a secret
+	// and never serialized; OwnerShareSlug shows it to the owner.
+	ShareSlug *string `json:"-" gorm:"size:32;uniqueIndex"`
+	// OwnerShareSlug is filled per request when the caller owns the agent.
+	OwnerShareSlug string `json:"shareSlug,omitempty" gorm:"-"`
+
+	// Denormalized from reviews, see handlers.refreshAgentRating.
+	RatingAverage float64 `json:"ratingAverage"`
+	RatingCount   uint    `json:"ratingCount"`
+
+	// LikedByMe is filled per request for authenticated callers.
+	LikedByMe bool `json:"likedByMe" gorm:"-"`
+
+	CategoryID *uint     `json:"categoryId" gorm:"index"`
+	Category   *Category `json:"category,omitempty" gorm:"constraint:OnDelete:SET NULL"`
+	Tags       []Tag     `json:"tags" gorm:"many2many:agent_tags"`
+
+	// Fork lineage. AllowForks is nil-able so that a request omitting it
+	// keeps the current setting; nil means forks are allowed.
+	ForkedFromID *uint `json:"forkedFromId" gorm:"index"`
+	ForkCount    uint  `json:"forkCount"`
+	AllowForks   *bool `json:"allowForks" gorm:"default:true"`
+
+	// LINE Messaging API channel the agent answers on. The credentials are
+	// never serialized; LineWebhook is the URL to register in the LINE console.
+	LineChannelSecret      string `json:"-"`
+	LineChannelAccessToken string `json:"-"`
+	LineWebhook            string `json:"lineWebhook"`
+}
+
+// ForksAllowed reports whether other users may fork the agent.
+func (a *Agent) ForksAllowed() bool {
+	return a.AllowForks == nil || *a.AllowForks
 }
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	EventView = "view"
+	EventChat = "chat"
+)
+
+// AgentEvent is a single view or chat of an agent. ViewerKey identifies
+// who it was ("user:12", "anon:<hash>", "line:<id>") so repeat views can be
+// ignored. Events are rolled up into AgentDailyStat and pruned later.
+type AgentEvent struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+	AgentID   uint      `json:"agentId" gorm:"index:idx_agent_events_viewer,priority:1;uniqueIndex:idx_agent_events_view_bucket,priority:1"`
+	Kind      string    `json:"kind" gorm:"size:16;index:idx_agent_events_viewer,priority:2"`
+	ViewerKey string    `json:"-" gorm:"size:96;index:idx_agent_events_viewer,priority:3;uniqueIndex:idx_agent_events_view_bucket,priority:2"`
+	// ViewBucket numbers the ViewWindow-long slot a view falls in and is
+	// nil for chats. The unique index turns a repeat view in the same slot
+	// into a no-op insert; nil never conflicts, so every chat is kept.
+	ViewBucket *int64 `json:"-" gorm:"uniqueIndex:idx_agent_events_view_bucket,priority:3"`
+}
+
+// AgentDailyStat is the per-day (UTC) roll-up of an agent's events.
+type AgentDailyStat struct {
+	AgentID       uint      `gorm:"primaryKey;autoIncrement:false"`
+	Day           time.Time `gorm:"primaryKey;type:date"`
+	Views         int64
+	UniqueViewers int64
+	Chats         int64
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// AgentLike marks an agent as liked (favorited) by a user. The composite
+// primary key makes liking idempotent.
+type AgentLike struct {
+	UserID    uint      `json:"userId" gorm:"primaryKey;autoIncrement:false"`
+	AgentID   uint      `json:"agentId" gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt time.Time `json:"createdAt"`
+}
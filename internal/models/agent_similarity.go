@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// AgentSimilarity is a cached "similar agents" result: SimilarAgentID is
+// the Rank-th best match for AgentID. The similar-agents job rebuilds the
+// whole table at startup and hourly; in between, when agents change, it only
+// rewrites the rows of those agents and of the agents whose matches they
+// enter or leave.
+type AgentSimilarity struct {
+	AgentID        uint `gorm:"primaryKey;autoIncrement:false"`
+	Rank           int  `gorm:"primaryKey;autoIncrement:false"`
+	SimilarAgentID uint
+	Score          float64
+	ComputedAt     time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// AgentTrendingScore is an agent's current time-decayed trending score,
+// recomputed in bulk by the trending job. Agents with no recent activity
+// have no row.
+type AgentTrendingScore struct {
+	AgentID    uint    `gorm:"primaryKey;autoIncrement:false"`
+	Score      float64 `gorm:"index"`
+	Views      int64   // counts within the trending window
+	Likes      int64
+	Chats      int64
+	ComputedAt time.Time
+}
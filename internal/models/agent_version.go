@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+// AgentVersion is an immutable snapshot of an agent's editable fields,
+// recorded every time the agent is created, updated or rolled back.
+type AgentVersion struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time `json:"createdAt"`
+	AgentID       uint      `json:"agentId" gorm:"uniqueIndex:idx_agent_versions_agent_version"`
+	Version       int       `json:"version" gorm:"uniqueIndex:idx_agent_versions_agent_version"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	Avatar        string    `json:"avatar"`
+	SystemPrompt  string    `json:"system_prompt"`
+	InputTemplate string    `json:"input_template"`
+	Personality   string    `json:"personality"`
+	CreatedByID   uint      `json:"createdById"`
+	Note          string    `json:"note"`
+}
+
+// FieldChange describes one field that differs between two versions.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// SnapshotAgent captures the versioned fields of agent.
+func SnapshotAgent(agent Agent) AgentVersion {
+	return AgentVersion{
+		AgentID:       agent.ID,
+		Name:          agent.Name,
+		Description:   agent.Description,
+		Avatar:        agent.Avatar,
+		SystemPrompt:  agent.SystemPrompt,
+		InputTemplate: agent.InputTemplate,
+		Personality:   agent.Personality,
+	}
+}
+
+// ApplyTo copies the versioned fields back onto agent.
+func (v AgentVersion) ApplyTo(agent *Agent) {
+	agent.Name = v.Name
+	agent.Description = v.Description
+	agent.Avatar = v.Avatar
+	agent.SystemPrompt = v.SystemPrompt
+	agent.InputTemplate = v.InputTemplate
+	agent.Personality = v.Personality
+}
+
+// Diff lists the fields that changed going from v to other, using the
+// same field names as the agent's JSON.
+func (v AgentVersion) Diff(other AgentVersion) []FieldChange {
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"name", v.Name, other.Name},
+		{"description", v.Description, other.Description},
+		{"avatar", v.Avatar, other.Avatar},
+		{"system_prompt", v.SystemPrompt, other.SystemPrompt},
+		{"input_template", v.InputTemplate, other.InputTemplate},
+		{"personality", v.Personality, other.Personality},
+	}
+
+	changes := []FieldChange{}
+	for _, f := range fields {
+		if f.from != f.to {
+			changes = append(changes, FieldChange{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey is a personal access key for scripts and CI jobs. Only a hash of
+// the key is stored; the full key is shown to the user once, on creation.
+type APIKey struct {
+	gorm.Model
+	UserID     uint       `json:"userId" gorm:"index"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix" gorm:"size:16"`
+	KeyHash    string     `json:"-" gorm:"size:64;uniqueIndex"`
+	Scopes     string     `json:"-"` // space separated, see ScopeList
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// HasScope reports whether the key was granted scope.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key can still be used.
+func (k *APIKey) Active() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt))
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type Conversation struct {
+	gorm.Model
+	UserID  uint   `json:"userId" gorm:"index"`
+	AgentID uint   `json:"agentId" gorm:"index"`
+	Title   string `json:"title"`
+	// LineUserID is set for conversations held with a LINE user rather than
+	// a hub account; UserID is zero for those.
+	LineUserID string    `json:"lineUserId,omitempty" gorm:"index"`
+	Messages   []Message `json:"messages,omitempty"`
+}
+
+type Message struct {
+	gorm.Model
+	ConversationID uint   `json:"conversationId" gorm:"index"`
+	Role           string `json:"role"`
+	Content        string `json:"content"`
+}
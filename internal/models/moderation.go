@@ -0,0 +1,15 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// ModerationAction is an audit log entry for an admin or moderator action.
+type ModerationAction struct {
+	gorm.Model
+	ActorID    uint   `json:"actorId" gorm:"index"`
+	Action     string `json:"action"`
+	TargetType string `json:"targetType" gorm:"size:32;index"`
+	TargetID   uint   `json:"targetId" gorm:"index"`
+	Reason     string `json:"reason"`
+}
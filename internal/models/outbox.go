@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxEmail is an email waiting to be delivered. Handlers write to the
+// outbox in the same transaction as the change that triggers the email, and
+// a background dispatcher sends it.
+type OutboxEmail struct {
+	gorm.Model
+	To            string     `json:"to"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"lastError"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"index"`
+	SentAt        *time.Time `json:"sentAt" gorm:"index"`
+	// LeasedUntil is set while a dispatcher is sending the email. Another
+	// dispatcher may take it over once the lease has expired.
+	LeasedUntil *time.Time `json:"leasedUntil"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is one link in a login session's chain of refresh tokens.
+// Every token issued for the same login shares a FamilyID, which is also
+// the session ID carried in access tokens.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint       `json:"userId" gorm:"index"`
+	FamilyID  string     `json:"-" gorm:"size:64;index"`
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`    // set once the token has been rotated
+	RevokedAt *time.Time `json:"revokedAt"` // set when the whole family is revoked
+}
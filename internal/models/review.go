@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// Review is a user's star rating of an agent with optional text. Each user
+// has at most one review per agent, which they can edit. Reviews are hard
+// deleted so the user can review again later.
+type Review struct {
+	ID             uint       `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	AgentID        uint       `json:"agentId" gorm:"uniqueIndex:idx_reviews_agent_user"`
+	UserID         uint       `json:"userId" gorm:"uniqueIndex:idx_reviews_agent_user;index"`
+	User           User       `json:"-"`
+	Rating         int        `json:"rating"`
+	Body           string     `json:"body"`
+	OwnerReply     string     `json:"ownerReply"`
+	OwnerRepliedAt *time.Time `json:"ownerRepliedAt"`
+}
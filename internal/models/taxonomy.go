@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Category is an entry in the admin-managed category taxonomy.
+type Category struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Slug        string    `json:"slug" gorm:"size:64;uniqueIndex"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+}
+
+// Tag is a free-form label owners attach to their agents. Names are
+// normalized to lower case, see handlers.normalizeTags.
+type Tag struct {
+	ID        uint      `json:"-" gorm:"primarykey"`
+	CreatedAt time.Time `json:"-"`
+	Name      string    `json:"name" gorm:"size:32;uniqueIndex"`
+}
+
+// MarshalJSON renders a tag as its bare name, matching how tags are sent
+// in requests.
+func (t Tag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Name)
+}
@@ -1,15 +1,40 @@
 package models
 
 import (
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// Roles a user can have, from least to most privileged.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	return role == RoleUser || role == RoleModerator || role == RoleAdmin
+}
+
+// User is never serialized directly with its credentials: Email and
+// Password are hidden from JSON, and handlers respond with the types in
+// package dto instead.
 type User struct {
 	gorm.Model
 	Username 	string 	`json:"username"`
-	Email    	string 	`json:"email"`
-	Password 	string 	`json:"password"`
+	Email    	string 	`json:"-"`
+	Password 	string 	`json:"-"`
+	Bio      	string 	`json:"bio"`
+	Avatar   	string 	`json:"avatar"`
+	// AvatarKey is the storage key prefix of an uploaded Avatar, empty when
+	// Avatar points elsewhere. Only files under it are ever deleted.
+	AvatarKey	string 	`json:"-"`
+	EmailVerifiedAt *time.Time `json:"-"`
+	Role     	string 	`json:"role" gorm:"size:16;default:user"`
+	DisabledAt 	*time.Time `json:"-"`
 	Agents 		[]Agent `json:"agents"`
 }
 
@@ -23,6 +48,16 @@ func (u *User) HashPassword() error {
 	return nil
 }
 
+// EmailVerified reports whether the user has confirmed their email address.
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// Disabled reports whether an admin has disabled the account.
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
 // CheckPassword compares plain text password with the hashed password
 func (u *User) CheckPassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
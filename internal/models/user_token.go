@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserToken records a single-use token sent to a user by email, such as an
+// email verification or password reset link. Only a hash of the token's
+// nonce is stored.
+type UserToken struct {
+	gorm.Model
+	UserID    uint       `json:"userId" gorm:"index"`
+	Purpose   string     `json:"purpose" gorm:"size:32;index"`
+	NonceHash string     `json:"-" gorm:"size:64;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+}
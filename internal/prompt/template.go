@@ -0,0 +1,279 @@
+// Package prompt implements the small template language used by
+// Agent.InputTemplate.
+//
+//	{{name}}                      substitutes a variable
+//	{{name|fallback text}}        substitutes a variable or a default
+//	{{#if name}}...{{/if}}        renders the body when name is non-empty
+//	{{#if name}}...{{else}}...{{/if}}
+//	\{{                           a literal "{{"
+//
+// Substituted values are inserted verbatim and are never parsed again, so
+// user input cannot inject template syntax.
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseError reports a malformed template and where the problem is.
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("template error at offset %d: %s", e.Pos, e.Msg)
+}
+
+// MissingVariableError is returned by Render when a required variable has
+// no value and no default.
+type MissingVariableError struct {
+	Name string
+}
+
+func (e *MissingVariableError) Error() string {
+	return fmt.Sprintf("missing value for variable %q", e.Name)
+}
+
+type nodeKind int
+
+const (
+	textNode nodeKind = iota
+	varNode
+	ifNode
+)
+
+type node struct {
+	kind       nodeKind
+	text       string // textNode
+	name       string // varNode, ifNode
+	def        string // varNode
+	hasDefault bool   // varNode
+	then, els  []node // ifNode
+}
+
+// Template is a parsed input template.
+type Template struct {
+	source string
+	nodes  []node
+}
+
+// Variable describes one variable a template expects.
+type Variable struct {
+	Name     string  `json:"name"`
+	Default  *string `json:"default,omitempty"`
+	Required bool    `json:"required"`
+}
+
+// Parse parses src into a Template.
+func Parse(src string) (*Template, error) {
+	p := &parser{src: src}
+	nodes, end, err := p.parseUntil()
+	if err != nil {
+		return nil, err
+	}
+	if end != "" {
+		return nil, &ParseError{Pos: p.pos, Msg: fmt.Sprintf("unexpected {{%s}}", end)}
+	}
+	return &Template{source: src, nodes: nodes}, nil
+}
+
+// Validate reports whether src is a well-formed template.
+func Validate(src string) error {
+	_, err := Parse(src)
+	return err
+}
+
+func (t *Template) Source() string {
+	return t.source
+}
+
+// Variables lists the variables referenced by the template in order of
+// first appearance. A variable is required when it is substituted without
+// a default outside of a conditional that tests it.
+func (t *Template) Variables() []Variable {
+	var vars []Variable
+	index := map[string]int{}
+
+	add := func(name string) *Variable {
+		if i, ok := index[name]; ok {
+			return &vars[i]
+		}
+		index[name] = len(vars)
+		vars = append(vars, Variable{Name: name})
+		return &vars[len(vars)-1]
+	}
+
+	var walk func(nodes []node, guarded map[string]bool)
+	walk = func(nodes []node, guarded map[string]bool) {
+		for _, n := range nodes {
+			switch n.kind {
+			case varNode:
+				v := add(n.name)
+				if n.hasDefault {
+					if v.Default == nil {
+						def := n.def
+						v.Default = &def
+					}
+				} else if !guarded[n.name] {
+					v.Required = true
+				}
+			case ifNode:
+				add(n.name)
+				inner := make(map[string]bool, len(guarded)+1)
+				for k := range guarded {
+					inner[k] = true
+				}
+				inner[n.name] = true
+				walk(n.then, inner)
+				walk(n.els, guarded)
+			}
+		}
+	}
+	walk(t.nodes, map[string]bool{})
+
+	return vars
+}
+
+// Render executes the template with the given variable values. Empty
+// values are treated the same as missing ones.
+func (t *Template) Render(vars map[string]string) (string, error) {
+	var b strings.Builder
+	if err := render(&b, t.nodes, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func render(b *strings.Builder, nodes []node, vars map[string]string) error {
+	for _, n := range nodes {
+		switch n.kind {
+		case textNode:
+			b.WriteString(n.text)
+		case varNode:
+			v := vars[n.name]
+			if v == "" {
+				if !n.hasDefault {
+					return &MissingVariableError{Name: n.name}
+				}
+				v = n.def
+			}
+			b.WriteString(v)
+		case ifNode:
+			body := n.els
+			if strings.TrimSpace(vars[n.name]) != "" {
+				body = n.then
+			}
+			if err := render(b, body, vars); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+// parseUntil parses nodes until the end of input or a block tag ("else" or
+// "/if"), which is returned so the caller can decide whether it is valid.
+func (p *parser) parseUntil() ([]node, string, error) {
+	var nodes []node
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, node{kind: textNode, text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.src) {
+		rest := p.src[p.pos:]
+
+		if strings.HasPrefix(rest, `\{{`) {
+			text.WriteString("{{")
+			p.pos += 3
+			continue
+		}
+		if !strings.HasPrefix(rest, "{{") {
+			text.WriteByte(p.src[p.pos])
+			p.pos++
+			continue
+		}
+
+		start := p.pos
+		end := strings.Index(rest, "}}")
+		if end < 0 {
+			return nil, "", &ParseError{Pos: start, Msg: "unclosed {{"}
+		}
+		tag := strings.TrimSpace(rest[2:end])
+		p.pos += end + 2
+
+		switch {
+		case tag == "else" || tag == "/if":
+			flush()
+			return nodes, tag, nil
+
+		case strings.HasPrefix(tag, "#if"):
+			name := strings.TrimSpace(strings.TrimPrefix(tag, "#if"))
+			if !namePattern.MatchString(name) {
+				return nil, "", &ParseError{Pos: start, Msg: fmt.Sprintf("invalid variable name %q in #if", name)}
+			}
+			flush()
+			n, err := p.parseIf(name, start)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, n)
+
+		case strings.HasPrefix(tag, "#") || strings.HasPrefix(tag, "/"):
+			return nil, "", &ParseError{Pos: start, Msg: fmt.Sprintf("unknown block {{%s}}", tag)}
+
+		default:
+			name, def, hasDefault := strings.Cut(tag, "|")
+			name = strings.TrimSpace(name)
+			if !namePattern.MatchString(name) {
+				return nil, "", &ParseError{Pos: start, Msg: fmt.Sprintf("invalid variable name %q", name)}
+			}
+			flush()
+			nodes = append(nodes, node{kind: varNode, name: name, def: strings.TrimSpace(def), hasDefault: hasDefault})
+		}
+	}
+
+	flush()
+	return nodes, "", nil
+}
+
+func (p *parser) parseIf(name string, start int) (node, error) {
+	n := node{kind: ifNode, name: name}
+
+	then, end, err := p.parseUntil()
+	if err != nil {
+		return n, err
+	}
+	n.then = then
+
+	if end == "else" {
+		els, end2, err := p.parseUntil()
+		if err != nil {
+			return n, err
+		}
+		if end2 == "else" {
+			return n, &ParseError{Pos: p.pos, Msg: "duplicate {{else}}"}
+		}
+		n.els = els
+		end = end2
+	}
+
+	if end != "/if" {
+		return n, &ParseError{Pos: start, Msg: fmt.Sprintf("{{#if %s}} is never closed", name)}
+	}
+	return n, nil
+}
@@ -0,0 +1,220 @@
+package prompt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		pos  int
+		msg  string
+	}{
+		{"unclosed tag", "Hello {{name", 6, "unclosed {{"},
+		{"stray close", "a{{/if}}", 8, "unexpected {{/if}}"},
+		{"stray else", "a{{else}}b", 9, "unexpected {{else}}"},
+		{"unclosed if", "{{#if x}}a", 0, "{{#if x}} is never closed"},
+		{"unclosed nested if", "{{#if a}}{{#if b}}x{{/if}}", 0, "{{#if a}} is never closed"},
+		{"unclosed inner if", "{{#if a}}x{{#if b}}y", 10, "{{#if b}} is never closed"},
+		{"duplicate else", "{{#if x}}a{{else}}b{{else}}c{{/if}}", 27, "duplicate {{else}}"},
+		{"unknown block", "{{#each items}}", 0, "unknown block {{#each items}}"},
+		{"unknown close", "{{/each}}", 0, "unknown block {{/each}}"},
+		{"bad variable", "{{1st}}", 0, `invalid variable name "1st"`},
+		{"empty variable", "x{{ }}", 1, `invalid variable name ""`},
+		{"bad if name", "{{#if my-var}}{{/if}}", 0, `invalid variable name "my-var" in #if`},
+		{"error inside if", "{{#if a}}{{b c}}{{/if}}", 9, `invalid variable name "b c"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse(%q) error = %v, want *ParseError", tt.src, err)
+			}
+			if perr.Pos != tt.pos || perr.Msg != tt.msg {
+				t.Errorf("Parse(%q) = {%d %q}, want {%d %q}", tt.src, perr.Pos, perr.Msg, tt.pos, tt.msg)
+			}
+			if Validate(tt.src) == nil {
+				t.Errorf("Validate(%q) = nil, want error", tt.src)
+			}
+		})
+	}
+}
+
+func TestValidateAcceptsWellFormedTemplates(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"plain text",
+		"{{input}}",
+		"{{ input }}",
+		"{{lang|}}",
+		"{{lang | French }}",
+		`\{{not a tag}}`,
+		`\{{`,
+		"}} {",
+		"{{#if a}}{{/if}}",
+		"{{#if a}}x{{else}}y{{/if}}",
+		"{{#if a}}{{#if b}}x{{else}}y{{/if}}{{else}}{{#if c}}z{{/if}}{{/if}}",
+	} {
+		if err := Validate(src); err != nil {
+			t.Errorf("Validate(%q) = %v", src, err)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		vars map[string]string
+		want string
+	}{
+		{"plain text", "no tags here", nil, "no tags here"},
+		{"variable", "Hi {{name}}!", map[string]string{"name": "Ann"}, "Hi Ann!"},
+		{"spaces in tag", "Hi {{ name }}!", map[string]string{"name": "Ann"}, "Hi Ann!"},
+		{"default used", "Into {{lang|French}}", nil, "Into French"},
+		{"default trimmed", "Into {{lang | French }}", nil, "Into French"},
+		{"empty value uses default", "Into {{lang|French}}", map[string]string{"lang": ""}, "Into French"},
+		{"value beats default", "Into {{lang|French}}", map[string]string{"lang": "German"}, "Into German"},
+		{"empty default", "[{{note|}}]", nil, "[]"},
+		{"default containing pipe", "{{sep|a|b}}", nil, "a|b"},
+		{"escaped tag", `\{{name}} is {{name}}`, map[string]string{"name": "Ann"}, "{{name}} is Ann"},
+		{"lone escape", `a \{{ b`, nil, "a {{ b"},
+		{"backslash kept elsewhere", `C:\path`, nil, `C:\path`},
+		{"values are not parsed", "{{input}}", map[string]string{"input": "{{secret}} \\{{x"}, "{{secret}} \\{{x"},
+		{"if true", "{{#if tone}}Be {{tone}}. {{/if}}Go", map[string]string{"tone": "kind"}, "Be kind. Go"},
+		{"if false", "{{#if tone}}Be {{tone}}. {{/if}}Go", nil, "Go"},
+		{"if blank is false", "{{#if tone}}yes{{else}}no{{/if}}", map[string]string{"tone": "  \n"}, "no"},
+		{"else branch", "{{#if a}}A{{else}}not A{{/if}}", nil, "not A"},
+		{"nested true", "{{#if a}}[{{#if b}}ab{{else}}a{{/if}}]{{/if}}", map[string]string{"a": "1", "b": "1"}, "[ab]"},
+		{"nested inner false", "{{#if a}}[{{#if b}}ab{{else}}a{{/if}}]{{/if}}", map[string]string{"a": "1"}, "[a]"},
+		{"nested outer false", "{{#if a}}[{{#if b}}ab{{else}}a{{/if}}]{{/if}}", map[string]string{"b": "1"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.src, err)
+			}
+			got, err := tmpl.Render(tt.vars)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMissingVariable(t *testing.T) {
+	tests := []struct {
+		src  string
+		vars map[string]string
+		want string
+	}{
+		{"Hi {{name}}", nil, "name"},
+		{"Hi {{name}}", map[string]string{"name": ""}, "name"},
+		{"{{a}} {{b}}", map[string]string{"a": "x"}, "b"},
+		{"{{#if a}}{{b}}{{/if}}", map[string]string{"a": "x"}, "b"},
+		{"{{#if a}}x{{else}}{{a}}{{/if}}", nil, "a"},
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse(tt.src)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.src, err)
+		}
+		_, err = tmpl.Render(tt.vars)
+		var merr *MissingVariableError
+		if !errors.As(err, &merr) || merr.Name != tt.want {
+			t.Errorf("Render(%q) error = %v, want missing %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []Variable
+	}{
+		{"none", "just text", nil},
+		{"escaped tag is not a variable", `\{{name}}`, nil},
+		{"required", "{{input}}", []Variable{{Name: "input", Required: true}}},
+		{"default", "{{lang|French}}", []Variable{{Name: "lang", Default: strPtr("French")}}},
+		{"empty default", "{{note|}}", []Variable{{Name: "note", Default: strPtr("")}}},
+		{
+			"order of first appearance",
+			"{{b}} {{a}} {{b}} {{c|x}}",
+			[]Variable{{Name: "b", Required: true}, {Name: "a", Required: true}, {Name: "c", Default: strPtr("x")}},
+		},
+		{
+			"first default wins",
+			"{{lang|French}} {{lang|German}}",
+			[]Variable{{Name: "lang", Default: strPtr("French")}},
+		},
+		{
+			"default and required use",
+			"{{lang|French}} {{lang}}",
+			[]Variable{{Name: "lang", Default: strPtr("French"), Required: true}},
+		},
+		{
+			"guarded by its own if",
+			"{{#if tone}}Be {{tone}}.{{/if}}",
+			[]Variable{{Name: "tone"}},
+		},
+		{
+			"else branch is not guarded",
+			"{{#if tone}}x{{else}}{{tone}}{{/if}}",
+			[]Variable{{Name: "tone", Required: true}},
+		},
+		{
+			"other variables inside if stay required",
+			"{{#if a}}{{b}}{{/if}}",
+			[]Variable{{Name: "a"}, {Name: "b", Required: true}},
+		},
+		{
+			"guard carries into nested blocks",
+			"{{#if a}}{{#if b}}{{a}}{{b}}{{/if}}{{/if}}",
+			[]Variable{{Name: "a"}, {Name: "b"}},
+		},
+		{
+			"guard ends with its block",
+			"{{#if a}}{{a}}{{/if}}{{a}}",
+			[]Variable{{Name: "a", Required: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.src, err)
+			}
+			if got := tmpl.Variables(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Variables() = %s, want %s", describe(got), describe(tt.want))
+			}
+		})
+	}
+}
+
+func describe(vars []Variable) string {
+	s := "["
+	for i, v := range vars {
+		if i > 0 {
+			s += " "
+		}
+		s += v.Name
+		if v.Default != nil {
+			s += "|" + *v.Default
+		}
+		if v.Required {
+			s += "!"
+		}
+	}
+	return s + "]"
+}
@@ -3,19 +3,40 @@ package routes
 import (
 	"ai-agent-hub/internal/handlers"
 	"ai-agent-hub/internal/middleware"
+	"ai-agent-hub/internal/models"
 
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
 )
 
 func RegisterPublicRoutes(e *echo.Echo, db *gorm.DB) {
-	e.POST("/api/auth/login", handlers.NewHandler(db).Login)                      //Login
-	e.POST("/api/auth/register", handlers.NewHandler(db).Register)                //Register
-	e.GET("/api/agents", handlers.NewHandler(db).GetAgents)                       //Get Agents List
-	e.GET("/api/agents/:id", handlers.NewHandler(db).GetAgentsByID)               //Get Agent Detail
-	e.GET("/api/user/:user_id/agents", handlers.NewHandler(db).GetAgentsOfUserID) //Get Agents List of UserID
-	e.GET("/api/agents/featured", handlers.NewHandler(db).GetFeaturedAgents)      //Get Featured Agents
-	e.GET("/api/agents/popular", handlers.NewHandler(db).GetPopularAgents)        //Get Popular Agents
+	optionalAuth := middleware.OptionalJWTMiddleware(db)
+
+	e.POST("/api/auth/login", handlers.NewHandler(db).Login)                                    //Login
+	e.POST("/api/auth/register", handlers.NewHandler(db).Register)                              //Register
+	e.POST("/api/auth/refresh", handlers.NewHandler(db).RefreshToken)                           //Refresh Access Token
+	e.POST("/api/auth/logout", handlers.NewHandler(db).Logout)                                  //Logout
+	e.POST("/api/auth/verify-email", handlers.NewHandler(db).VerifyEmail)                       //Verify Email
+	e.POST("/api/auth/forgot-password", handlers.NewHandler(db).ForgotPassword)                 //Request Password Reset
+	e.POST("/api/auth/reset-password", handlers.NewHandler(db).ResetPassword)                   //Reset Password
+	e.GET("/api/agents", handlers.NewHandler(db).GetAgents, optionalAuth)                       //Get Agents List
+	e.GET("/api/agents/:id", handlers.NewHandler(db).GetAgentsByID, optionalAuth)               //Get Agent Detail
+	e.GET("/api/user/:user_id/agents", handlers.NewHandler(db).GetAgentsOfUserID, optionalAuth) //Get Agents List of UserID
+	e.GET("/api/users/:username", handlers.NewHandler(db).GetUserProfile)                       //Get Public Profile
+	e.GET("/api/agents/search", handlers.NewHandler(db).SearchAgents, optionalAuth)             //Search Agents
+	e.GET("/api/agents/trending", handlers.NewHandler(db).GetTrendingAgents, optionalAuth)      //Get Trending Agents
+	e.GET("/api/agents/featured", handlers.NewHandler(db).GetFeaturedAgents, optionalAuth)      //Get Featured Agents
+	e.GET("/api/agents/popular", handlers.NewHandler(db).GetPopularAgents, optionalAuth)        //Get Popular Agents
+	e.GET("/api/agents/top-rated", handlers.NewHandler(db).GetTopRatedAgents, optionalAuth)     //Get Top Rated Agents
+	e.GET("/api/agents/:id/similar", handlers.NewHandler(db).GetSimilarAgents, optionalAuth)    //Get Similar Agents
+	e.GET("/api/agents/:id/reviews", handlers.NewHandler(db).GetAgentReviews, optionalAuth)     //Get Agent Reviews
+	e.GET("/api/agents/:id/template", handlers.NewHandler(db).GetAgentTemplate, optionalAuth)   //Get Agent Input Template Variables
+	e.GET("/api/avatars/:seed", handlers.NewHandler(db).GetIdenticon)                           //Identicon Avatar
+	e.GET("/api/media/*", handlers.NewHandler(db).ServeMedia)                                   //Serve Uploaded Files
+	e.GET("/api/categories", handlers.NewHandler(db).GetCategories)                             //Get Categories
+	e.GET("/api/tags", handlers.NewHandler(db).GetTagCloud)                                     //Get Tag Cloud
+	e.POST("/api/templates/inspect", handlers.NewHandler(db).InspectTemplate)                   //Validate Template
+	e.POST("/api/line/webhook/:agent_id", handlers.NewHandler(db).LineWebhook)                  //LINE Webhook
 }
 
 func RegisterPrivateRoutes(e *echo.Echo, db *gorm.DB) {
@@ -26,18 +47,65 @@ func RegisterPrivateRoutes(e *echo.Echo, db *gorm.DB) {
 	// * PUT /api/user/:user_id/agents/:agent_id: Update a user's agent (requires authentication).
 	// * DELETE /api/user/:user_id/agents/:agent_id: Delete a user's agent (requires authentication).
 
-	r := e.Group("/api/my", middleware.JWTMiddleware())
+	// Accepts a JWT or an API key. API keys are limited to their scopes and
+	// can never reach /api-keys, so a leaked key cannot mint new ones.
+	r := e.Group("/api/my", middleware.AuthMiddleware(db, "/api/my"))
 	r.GET("/agents", handlers.NewHandler(db).GetMyAgents)
+	r.GET("/agents/export", handlers.NewHandler(db).ExportMyAgents)
+	r.POST("/agents/import", handlers.NewHandler(db).ImportMyAgents)
 	r.GET("/agents/:id", handlers.NewHandler(db).GetMyAgentByID)
 	r.POST("/agents", handlers.NewHandler(db).CreateMyAgents)
 	r.PUT("/agents/:id", handlers.NewHandler(db).UpdateMyAgent)
 	r.DELETE("/agents/:id", handlers.NewHandler(db).DeleteMyAgent)
+	r.PUT("/agents/:id/avatar", handlers.NewHandler(db).UploadAgentAvatar)
+	r.GET("/agents/:id/export", handlers.NewHandler(db).ExportMyAgent)
+	r.GET("/agents/:id/analytics", handlers.NewHandler(db).GetMyAgentAnalytics)
+	r.GET("/agents/:id/versions", handlers.NewHandler(db).GetMyAgentVersions)
+	r.GET("/agents/:id/versions/diff", handlers.NewHandler(db).DiffMyAgentVersions)
+	r.GET("/agents/:id/versions/:version", handlers.NewHandler(db).GetMyAgentVersion)
+	r.POST("/agents/:id/versions/:version/rollback", handlers.NewHandler(db).RollbackMyAgent)
+	r.PUT("/agents/:id/reviews/:review_id/reply", handlers.NewHandler(db).ReplyToReview)
+	r.POST("/agents/:id/line", handlers.NewHandler(db).LinkAgentToLine)
+	r.DELETE("/agents/:id/line", handlers.NewHandler(db).UnlinkAgentFromLine)
+	r.GET("/profile", handlers.NewHandler(db).GetProfile)
+	r.PUT("/profile", handlers.NewHandler(db).UpdateProfile)
+	r.PUT("/profile/avatar", handlers.NewHandler(db).UploadProfileAvatar)
+	r.POST("/resend-verification", handlers.NewHandler(db).ResendVerification)
+	r.GET("/api-keys", handlers.NewHandler(db).GetMyAPIKeys)
+	r.POST("/api-keys", handlers.NewHandler(db).CreateMyAPIKey)
+	r.DELETE("/api-keys/:id", handlers.NewHandler(db).RevokeMyAPIKey)
+	r.GET("/favorites", handlers.NewHandler(db).GetMyFavorites)
+	r.GET("/conversations", handlers.NewHandler(db).GetMyConversations)
+	r.GET("/conversations/:id", handlers.NewHandler(db).GetMyConversation)
+	r.DELETE("/conversations/:id", handlers.NewHandler(db).DeleteMyConversation)
+
+	e.POST("/api/agents/:id/chat", handlers.NewHandler(db).ChatWithAgent, middleware.JWTMiddleware(db))              //Chat with Agent
+	e.POST("/api/agents/:id/chat/stream", handlers.NewHandler(db).StreamChatWithAgent, middleware.JWTMiddleware(db)) //Stream Chat with Agent
+	e.POST("/api/agents/:id/fork", handlers.NewHandler(db).ForkAgent, middleware.JWTMiddleware(db))                  //Fork Agent
+	e.PUT("/api/agents/:id/like", handlers.NewHandler(db).LikeAgent, middleware.JWTMiddleware(db))                   //Like Agent
+	e.DELETE("/api/agents/:id/like", handlers.NewHandler(db).UnlikeAgent, middleware.JWTMiddleware(db))              //Unlike Agent
+	e.PUT("/api/agents/:id/review", handlers.NewHandler(db).UpsertAgentReview, middleware.JWTMiddleware(db))         //Rate/Review Agent
+	e.DELETE("/api/agents/:id/review", handlers.NewHandler(db).DeleteAgentReview, middleware.JWTMiddleware(db))      //Delete My Review
 
 	// r.POST("/agents", handlers.CreateAgent(db))
 	// r.PUT("/agents/:id", handlers.UpdateAgent(db))
 	// r.DELETE("/agents/:id", handlers.DeleteAgent(db))
-	// r.POST("/agents/:id/chat", handlers.ChatWithAgent(db))
-	// r.POST("/agents/:id/line/link", handlers.LinkAgentLine(db))
-	// r.GET("/profile", handlers.GetProfile(db))
-	// r.PUT("/profile", handlers.UpdateProfile(db))
+}
+
+func RegisterAdminRoutes(e *echo.Echo, db *gorm.DB) {
+	staff := middleware.RequireRole(models.RoleModerator, models.RoleAdmin)
+	adminOnly := middleware.RequireRole(models.RoleAdmin)
+
+	a := e.Group("/api/admin", middleware.JWTMiddleware(db), staff)
+	a.PUT("/agents/:id/featured", handlers.NewHandler(db).AdminSetAgentFeatured)
+	a.DELETE("/agents/:id", handlers.NewHandler(db).AdminDeleteAgent)
+	a.DELETE("/reviews/:id", handlers.NewHandler(db).AdminDeleteReview)
+	a.GET("/moderation-log", handlers.NewHandler(db).AdminModerationLog)
+
+	a.POST("/categories", handlers.NewHandler(db).AdminCreateCategory, adminOnly)
+	a.PUT("/categories/:id", handlers.NewHandler(db).AdminUpdateCategory, adminOnly)
+	a.DELETE("/categories/:id", handlers.NewHandler(db).AdminDeleteCategory, adminOnly)
+	a.GET("/users", handlers.NewHandler(db).AdminListUsers, adminOnly)
+	a.PUT("/users/:id/role", handlers.NewHandler(db).AdminSetUserRole, adminOnly)
+	a.PUT("/users/:id/status", handlers.NewHandler(db).AdminSetUserStatus, adminOnly)
 }
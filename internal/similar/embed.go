@@ -0,0 +1,163 @@
+package similar
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Embedder turns texts into vectors whose cosine similarity reflects how
+// related the texts are. Fit is given the whole corpus, so implementations
+// may learn corpus statistics such as IDF; Embed then reuses them, which
+// lets single agents be embedded between fits.
+type Embedder interface {
+	Fit(ctx context.Context, texts []string) error
+	Embed(ctx context.Context, texts []string) ([]Vector, error)
+}
+
+// Vector is a sparse, normalized embedding. Its components are sorted by
+// dimension.
+type Vector []Component
+
+type Component struct {
+	Dim    int32
+	Weight float32
+}
+
+// TFIDFEmbedder is the local Embedder: TF-IDF weights fitted on the corpus,
+// feature-hashed into a fixed number of dimensions.
+type TFIDFEmbedder struct {
+	Dims int
+
+	mu sync.RWMutex
+	df map[string]int
+	n  int
+}
+
+func NewTFIDFEmbedder(dims int) *TFIDFEmbedder {
+	return &TFIDFEmbedder{Dims: dims}
+}
+
+// Fit counts document frequencies over the corpus.
+func (e *TFIDFEmbedder) Fit(ctx context.Context, texts []string) error {
+	df := map[string]int{}
+	for _, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		seen := map[string]bool{}
+		for _, tok := range tokenize(text) {
+			if !seen[tok] {
+				seen[tok] = true
+				df[tok]++
+			}
+		}
+	}
+
+	e.mu.Lock()
+	e.df, e.n = df, len(texts)
+	e.mu.Unlock()
+	return nil
+}
+
+// Embed weights each text's terms by the IDF of the last Fit. Terms the
+// corpus has never seen get the highest IDF.
+func (e *TFIDFEmbedder) Embed(ctx context.Context, texts []string) ([]Vector, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	n := float64(e.n)
+	vectors := make([]Vector, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		terms := map[string]int{}
+		for _, tok := range tokenize(text) {
+			terms[tok]++
+		}
+
+		weights := map[int32]float32{}
+		for tok, count := range terms {
+			tf := 1 + math.Log(float64(count))
+			idf := math.Log((1+n)/(1+float64(e.df[tok]))) + 1
+			weights[e.bucket(tok)] += float32(tf * idf)
+		}
+
+		v := make(Vector, 0, len(weights))
+		for dim, w := range weights {
+			v = append(v, Component{Dim: dim, Weight: w})
+		}
+		sort.Slice(v, func(a, b int) bool { return v[a].Dim < v[b].Dim })
+		normalize(v)
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// bucket hashes a token to a dimension. Collisions only ever add a little
+// similarity, since all weights are positive.
+func (e *TFIDFEmbedder) bucket(tok string) int32 {
+	h := fnv.New32a()
+	h.Write([]byte(tok))
+	return int32(h.Sum32() % uint32(e.Dims))
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "has": true, "have": true, "in": true, "is": true,
+	"it": true, "its": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "were": true, "will": true, "with": true,
+	"you": true, "your": true, "i": true, "me": true, "my": true, "we": true, "our": true,
+}
+
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	tokens := words[:0]
+	for _, w := range words {
+		if len([]rune(w)) > 1 && !stopWords[w] {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+func normalize(v Vector) {
+	var sum float64
+	for _, c := range v {
+		sum += float64(c.Weight) * float64(c.Weight)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i].Weight /= norm
+	}
+}
+
+// cosine assumes both vectors are normalized.
+func cosine(a, b Vector) float64 {
+	var dot float64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].Dim < b[j].Dim:
+			i++
+		case a[i].Dim > b[j].Dim:
+			j++
+		default:
+			dot += float64(a[i].Weight) * float64(b[j].Weight)
+			i++
+			j++
+		}
+	}
+	return dot
+}
@@ -0,0 +1,244 @@
+package similar
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Candidate prefilter. An agent is only scored against agents it shares a
+// tag, a user or one of its heaviest text terms with, so scoring one agent
+// costs O(maxCandidates) rather than O(agents).
+const (
+	// postingTerms is how many of an agent's heaviest text dimensions it is
+	// filed under.
+	postingTerms = 8
+	// maxPosting skips keys shared by so many agents that they don't narrow
+	// anything down, like stop words.
+	maxPosting = 5000
+	// maxCandidates keeps the agents sharing the most keys.
+	maxCandidates = 500
+)
+
+// entry is what the index knows about one agent.
+type entry struct {
+	vec   Vector
+	tags  map[string]bool
+	users map[uint]bool
+	keys  []string
+}
+
+type match struct {
+	id    uint
+	score float64
+}
+
+// index caches every agent's signals and top matches in memory, so that a
+// change to a few agents only rescores those agents and their neighbours.
+type index struct {
+	entries  map[uint]*entry
+	postings map[string]map[uint]bool
+	top      map[uint][]match
+}
+
+func newIndex() *index {
+	return &index{
+		entries:  map[uint]*entry{},
+		postings: map[string]map[uint]bool{},
+		top:      map[uint][]match{},
+	}
+}
+
+// put adds or replaces an agent.
+func (x *index) put(id uint, e *entry) {
+	x.remove(id)
+
+	for tag := range e.tags {
+		e.keys = append(e.keys, "t:"+tag)
+	}
+	for user := range e.users {
+		e.keys = append(e.keys, fmt.Sprintf("u:%d", user))
+	}
+	heaviest := append(Vector(nil), e.vec...)
+	sort.Slice(heaviest, func(a, b int) bool { return heaviest[a].Weight > heaviest[b].Weight })
+	for i := 0; i < len(heaviest) && i < postingTerms; i++ {
+		e.keys = append(e.keys, fmt.Sprintf("w:%d", heaviest[i].Dim))
+	}
+
+	for _, k := range e.keys {
+		if x.postings[k] == nil {
+			x.postings[k] = map[uint]bool{}
+		}
+		x.postings[k][id] = true
+	}
+	x.entries[id] = e
+}
+
+// remove drops an agent and its cached matches.
+func (x *index) remove(id uint) {
+	e, ok := x.entries[id]
+	if !ok {
+		return
+	}
+	for _, k := range e.keys {
+		delete(x.postings[k], id)
+		if len(x.postings[k]) == 0 {
+			delete(x.postings, k)
+		}
+	}
+	delete(x.entries, id)
+	delete(x.top, id)
+}
+
+// candidates lists the agents worth scoring against id, most shared keys
+// first.
+func (x *index) candidates(id uint) []uint {
+	e, ok := x.entries[id]
+	if !ok {
+		return nil
+	}
+
+	shared := map[uint]int{}
+	for _, k := range e.keys {
+		if len(x.postings[k]) > maxPosting {
+			continue
+		}
+		for other := range x.postings[k] {
+			if other != id {
+				shared[other]++
+			}
+		}
+	}
+
+	ids := make([]uint, 0, len(shared))
+	for other := range shared {
+		ids = append(ids, other)
+	}
+	if len(ids) > maxCandidates {
+		sort.Slice(ids, func(a, b int) bool {
+			if shared[ids[a]] != shared[ids[b]] {
+				return shared[ids[a]] > shared[ids[b]]
+			}
+			return ids[a] < ids[b]
+		})
+		ids = ids[:maxCandidates]
+	}
+	return ids
+}
+
+// score combines text similarity, tag overlap and co-usage of two agents.
+func (x *index) score(a, b uint) float64 {
+	ea, eb := x.entries[a], x.entries[b]
+	return textWeight*cosine(ea.vec, eb.vec) +
+		tagWeight*jaccard(ea.tags, eb.tags) +
+		usageWeight*overlap(ea.users, eb.users)
+}
+
+// rank recomputes and caches id's top matches.
+func (x *index) rank(id uint) []match {
+	var matches []match
+	for _, other := range x.candidates(id) {
+		if s := x.score(id, other); s >= minScore {
+			matches = append(matches, match{id: other, score: s})
+		}
+	}
+
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].score != matches[b].score {
+			return matches[a].score > matches[b].score
+		}
+		return matches[a].id < matches[b].id
+	})
+	if len(matches) > TopK {
+		matches = matches[:TopK]
+	}
+
+	x.top[id] = matches
+	return matches
+}
+
+// update applies changes to the agents in ids: those in entries were
+// created or changed, the rest were deleted. It reranks the changed agents
+// and every agent whose top matches they may enter or leave, and returns
+// the reranked and the removed agents.
+func (x *index) update(ids []uint, entries map[uint]*entry) (ranked, removed []uint) {
+	changed := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		changed[id] = true
+	}
+	affected := map[uint]bool{}
+	for _, id := range x.referencing(changed) {
+		affected[id] = true
+	}
+
+	for _, id := range ids {
+		if e, ok := entries[id]; ok {
+			x.put(id, e)
+		} else {
+			x.remove(id)
+			removed = append(removed, id)
+		}
+	}
+
+	// A changed agent may now belong in the top matches of its neighbours.
+	for id := range entries {
+		affected[id] = true
+		for _, other := range x.candidates(id) {
+			if x.score(id, other) >= minScore {
+				affected[other] = true
+			}
+		}
+	}
+
+	for id := range affected {
+		if _, ok := x.entries[id]; ok {
+			x.rank(id)
+			ranked = append(ranked, id)
+		}
+	}
+	return ranked, removed
+}
+
+// referencing lists the agents whose cached matches include any of ids.
+func (x *index) referencing(ids map[uint]bool) []uint {
+	var out []uint
+	for id, matches := range x.top {
+		for _, m := range matches {
+			if ids[m.id] {
+				out = append(out, id)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for k := range a {
+		if b[k] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// overlap is the cosine similarity of two user sets.
+func overlap(a, b map[uint]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for k := range a {
+		if b[k] {
+			shared++
+		}
+	}
+	return float64(shared) / math.Sqrt(float64(len(a))*float64(len(b)))
+}
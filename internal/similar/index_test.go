@@ -0,0 +1,144 @@
+package similar
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type testAgent struct {
+	id    uint
+	text  string
+	tags  []string
+	users []uint
+}
+
+func buildEntries(t *testing.T, e Embedder, agents []testAgent) map[uint]*entry {
+	t.Helper()
+	texts := make([]string, len(agents))
+	for i, a := range agents {
+		texts[i] = a.text
+	}
+	vectors, err := e.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+
+	entries := map[uint]*entry{}
+	for i, a := range agents {
+		en := &entry{vec: vectors[i], tags: map[string]bool{}, users: map[uint]bool{}}
+		for _, tag := range a.tags {
+			en.tags[tag] = true
+		}
+		for _, u := range a.users {
+			en.users[u] = true
+		}
+		entries[a.id] = en
+	}
+	return entries
+}
+
+// fullIndex ranks every agent from scratch, as RefreshOnce does.
+func fullIndex(entries map[uint]*entry) *index {
+	x := newIndex()
+	for id, e := range entries {
+		x.put(id, &entry{vec: e.vec, tags: e.tags, users: e.users})
+	}
+	for id := range entries {
+		x.rank(id)
+	}
+	return x
+}
+
+var corpus = []testAgent{
+	{1, "Python tutor that explains code and debugging", []string{"python", "education"}, []uint{10}},
+	{2, "Go code reviewer for concurrency and debugging", []string{"golang", "code-review"}, []uint{10, 11}},
+	{3, "Italian cooking assistant with pasta recipes", []string{"cooking"}, []uint{12}},
+	{4, "Baking helper for bread and pastry recipes", []string{"cooking", "baking"}, []uint{12, 13}},
+	{5, "Travel planner for Italy itineraries", []string{"travel"}, nil},
+	{6, "Python data analysis helper with pandas code", []string{"python", "data"}, []uint{11}},
+	{7, "Poetry writing coach", nil, nil},
+}
+
+func TestIndexRanksBySharedSignals(t *testing.T) {
+	e := NewTFIDFEmbedder(1024)
+	texts := make([]string, len(corpus))
+	for i, a := range corpus {
+		texts[i] = a.text
+	}
+	e.Fit(context.Background(), texts)
+	x := fullIndex(buildEntries(t, e, corpus))
+
+	if top := x.top[3]; len(top) == 0 || top[0].id != 4 {
+		t.Errorf("top match for the cooking agent = %+v, want the baking agent", top)
+	}
+	if top := x.top[1]; len(top) < 2 || top[0].id != 2 || top[1].id != 6 {
+		t.Errorf("top matches for the python tutor = %+v, want the code agents first", top)
+	}
+	if len(x.top[7]) != 0 {
+		t.Errorf("agent sharing nothing got matches %+v", x.top[7])
+	}
+	for _, c := range x.candidates(1) {
+		if c == 7 {
+			t.Error("agent sharing no keys was a candidate")
+		}
+	}
+}
+
+func TestIndexUpdateMatchesFullRebuild(t *testing.T) {
+	e := NewTFIDFEmbedder(1024)
+	texts := make([]string, len(corpus))
+	for i, a := range corpus {
+		texts[i] = a.text
+	}
+	e.Fit(context.Background(), texts)
+
+	x := fullIndex(buildEntries(t, e, corpus))
+
+	// Agent 7 turns into a cooking agent, 5 is deleted and 8 is created.
+	changes := []testAgent{
+		{7, "Vegetarian cooking ideas and pasta recipes", []string{"cooking"}, []uint{13}},
+		{8, "Go concurrency tutor with code examples", []string{"golang", "education"}, []uint{11}},
+	}
+	changed := buildEntries(t, e, changes)
+	_, removed := x.update([]uint{7, 8, 5}, changed)
+
+	if !reflect.DeepEqual(removed, []uint{5}) {
+		t.Errorf("removed = %v, want [5]", removed)
+	}
+
+	after := buildEntries(t, e, corpus)
+	delete(after, 5)
+	for id, en := range changed {
+		after[id] = en
+	}
+	want := fullIndex(after)
+
+	if len(x.entries) != len(want.entries) {
+		t.Fatalf("index has %d agents, want %d", len(x.entries), len(want.entries))
+	}
+	for id := range want.entries {
+		if !reflect.DeepEqual(x.top[id], want.top[id]) {
+			t.Errorf("agent %d: incremental top %+v, full rebuild %+v", id, x.top[id], want.top[id])
+		}
+	}
+}
+
+func TestTFIDFEmbedderReusesFittedIDF(t *testing.T) {
+	e := NewTFIDFEmbedder(64)
+	e.Fit(context.Background(), []string{"common rare", "common", "common"})
+
+	vs, err := e.Embed(context.Background(), []string{"common rare", "common rare"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if got := cosine(vs[0], vs[1]); got < 0.999 {
+		t.Errorf("cosine of identical texts = %v", got)
+	}
+
+	// Embedding one text alone must give the same vector as in a batch.
+	one, _ := e.Embed(context.Background(), []string{"common rare"})
+	if !reflect.DeepEqual(one[0], vs[0]) {
+		t.Errorf("vector depends on the batch: %v vs %v", one[0], vs[0])
+	}
+}
@@ -0,0 +1,290 @@
+package similar
+
+import (
+	"ai-agent-hub/internal/analytics"
+	"ai-agent-hub/internal/models"
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// TopK is how many similar agents are cached per agent.
+	TopK = 20
+	// minScore drops matches that only share noise.
+	minScore = 0.05
+
+	refreshDebounce     = 5 * time.Second
+	fullRefreshInterval = time.Hour
+)
+
+// How much each signal contributes to the similarity score.
+const (
+	textWeight  = 0.5
+	tagWeight   = 0.3
+	usageWeight = 0.2
+)
+
+var (
+	dirty    = make(chan struct{}, 1)
+	dirtyMu  sync.Mutex
+	dirtyIDs = map[uint]bool{}
+)
+
+// MarkDirty asks the refresher to recompute the given agents soon. Call it
+// after anything that changes an agent's text or tags, or adds or removes
+// an agent.
+func MarkDirty(agentIDs ...uint) {
+	dirtyMu.Lock()
+	for _, id := range agentIDs {
+		dirtyIDs[id] = true
+	}
+	dirtyMu.Unlock()
+
+	select {
+	case dirty <- struct{}{}:
+	default:
+	}
+}
+
+func takeDirty() []uint {
+	dirtyMu.Lock()
+	defer dirtyMu.Unlock()
+
+	ids := make([]uint, 0, len(dirtyIDs))
+	for id := range dirtyIDs {
+		ids = append(ids, id)
+	}
+	dirtyIDs = map[uint]bool{}
+	return ids
+}
+
+// Refresher keeps agent_similarities up to date. Changed agents are
+// rescored incrementally against an in-memory index; a full rebuild every
+// hour picks up new co-usage, refits the text model and catches changes
+// made on other instances. A Refresher is not safe for concurrent use.
+type Refresher struct {
+	DB       *gorm.DB
+	Embedder Embedder
+
+	index *index
+}
+
+func NewRefresher(db *gorm.DB) *Refresher {
+	return &Refresher{DB: db, Embedder: NewTFIDFEmbedder(1024)}
+}
+
+// Run recomputes until ctx is cancelled.
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(fullRefreshInterval)
+	defer ticker.Stop()
+
+	if err := r.RefreshOnce(ctx); err != nil {
+		log.Printf("similar agents: %v", err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.RefreshOnce(ctx); err != nil {
+				log.Printf("similar agents: %v", err)
+			}
+		case <-dirty:
+			// Let a burst of edits settle before recomputing.
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(refreshDebounce):
+			}
+			select {
+			case <-dirty:
+			default:
+			}
+			if err := r.RefreshAgents(ctx, takeDirty()); err != nil {
+				log.Printf("similar agents: %v", err)
+			}
+		}
+	}
+}
+
+// RefreshOnce rebuilds the index from every agent and replaces the cached
+// top matches in one transaction. If another instance is writing at the
+// same time, only the local index is rebuilt.
+func (r *Refresher) RefreshOnce(ctx context.Context) error {
+	db := r.DB.WithContext(ctx)
+
+	var agents []models.Agent
+	if err := db.Select("id", "name", "description", "system_prompt").Preload("Tags").
+		Order("id").Find(&agents).Error; err != nil {
+		return err
+	}
+
+	texts := agentTexts(agents)
+	if err := r.Embedder.Fit(ctx, texts); err != nil {
+		return err
+	}
+	vectors, err := r.Embedder.Embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+	users, err := r.usage(db, nil)
+	if err != nil {
+		return err
+	}
+
+	idx := newIndex()
+	for i, a := range agents {
+		idx.put(a.ID, newEntry(a, vectors[i], users[a.ID]))
+	}
+
+	now := time.Now()
+	var rows []models.AgentSimilarity
+	for _, a := range agents {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rows = appendRows(rows, a.ID, idx.rank(a.ID), now)
+	}
+	r.index = idx
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if locked, err := analytics.TryJobLock(tx, analytics.JobSimilar); err != nil || !locked {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM agent_similarities").Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+}
+
+// RefreshAgents rescores the given agents, which were created, changed or
+// deleted, and the agents whose top matches they may enter or leave. Only
+// those agents' rows are rewritten.
+func (r *Refresher) RefreshAgents(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if r.index == nil {
+		return r.RefreshOnce(ctx)
+	}
+	db := r.DB.WithContext(ctx)
+	idx := r.index
+
+	var agents []models.Agent
+	if err := db.Select("id", "name", "description", "system_prompt").Preload("Tags").
+		Where("id IN ?", ids).Find(&agents).Error; err != nil {
+		return err
+	}
+	vectors, err := r.Embedder.Embed(ctx, agentTexts(agents))
+	if err != nil {
+		return err
+	}
+	users, err := r.usage(db, ids)
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[uint]*entry, len(agents))
+	for i, a := range agents {
+		entries[a.ID] = newEntry(a, vectors[i], users[a.ID])
+	}
+	ranked, removed := idx.update(ids, entries)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var rows []models.AgentSimilarity
+	rewrite := append([]uint(nil), removed...)
+	for _, id := range ranked {
+		rows = appendRows(rows, id, idx.top[id], now)
+		rewrite = append(rewrite, id)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Wait for a full rebuild on another instance rather than race it.
+		if err := analytics.JobLock(tx, analytics.JobSimilar); err != nil {
+			return err
+		}
+		if err := tx.Where("agent_id IN ?", rewrite).Delete(&models.AgentSimilarity{}).Error; err != nil {
+			return err
+		}
+		if len(removed) > 0 {
+			if err := tx.Where("similar_agent_id IN ?", removed).Delete(&models.AgentSimilarity{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+}
+
+func agentTexts(agents []models.Agent) []string {
+	texts := make([]string, len(agents))
+	for i, a := range agents {
+		texts[i] = a.Name + "\n" + a.Description + "\n" + a.SystemPrompt
+	}
+	return texts
+}
+
+func newEntry(a models.Agent, vec Vector, users map[uint]bool) *entry {
+	e := &entry{vec: vec, tags: map[string]bool{}, users: users}
+	for _, t := range a.Tags {
+		e.tags[t.Name] = true
+	}
+	return e
+}
+
+func appendRows(rows []models.AgentSimilarity, agentID uint, matches []match, now time.Time) []models.AgentSimilarity {
+	for rank, m := range matches {
+		rows = append(rows, models.AgentSimilarity{
+			AgentID:        agentID,
+			Rank:           rank + 1,
+			SimilarAgentID: m.id,
+			Score:          m.score,
+			ComputedAt:     now,
+		})
+	}
+	return rows
+}
+
+// usage maps each agent to the users who liked it or chatted with it,
+// limited to agentIDs when given.
+func (r *Refresher) usage(db *gorm.DB, agentIDs []uint) (map[uint]map[uint]bool, error) {
+	var pairs []struct {
+		AgentID uint
+		UserID  uint
+	}
+	q := `SELECT agent_id, user_id FROM agent_likes
+		UNION
+		SELECT agent_id, user_id FROM conversations WHERE user_id <> 0 AND deleted_at IS NULL`
+	var args []any
+	if agentIDs != nil {
+		q = `SELECT agent_id, user_id FROM agent_likes WHERE agent_id IN @ids
+		UNION
+		SELECT agent_id, user_id FROM conversations WHERE user_id <> 0 AND deleted_at IS NULL AND agent_id IN @ids`
+		args = append(args, map[string]any{"ids": agentIDs})
+	}
+	if err := db.Raw(q, args...).Scan(&pairs).Error; err != nil {
+		return nil, err
+	}
+
+	users := map[uint]map[uint]bool{}
+	for _, p := range pairs {
+		if users[p.AgentID] == nil {
+			users[p.AgentID] = map[uint]bool{}
+		}
+		users[p.AgentID][p.UserID] = true
+	}
+	return users, nil
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// LocalStorage keeps files under a directory on disk.
+type LocalStorage struct {
+	Root string
+}
+
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{Root: root}
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file and renames it into place, so readers
+// never see a half-written file.
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Get infers the content type from the key's extension.
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, Object{}, ErrNotFound
+	}
+
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Object{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Object{}, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, Object{}, err
+	}
+
+	return f, Object{
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+	}, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Storage keeps files in a bucket of any S3-compatible service (AWS S3,
+// MinIO, R2, ...), addressed path-style as <endpoint>/<bucket>/<key>.
+// Requests are signed with AWS Signature Version 4.
+type S3Storage struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	HTTP      *http.Client
+}
+
+func NewS3Storage(endpoint, region, bucket, accessKey, secretKey string) *S3Storage {
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3Storage{
+		Endpoint:  strings.TrimRight(endpoint, "/"),
+		Region:    region,
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		HTTP:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.error("put", key, resp)
+	}
+	return nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, Object{}, ErrNotFound
+	}
+
+	resp, err := s.HTTP.Do(req)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, Object{}, ErrNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, Object{}, s.error("get", key, resp)
+	}
+
+	obj := Object{ContentType: resp.Header.Get("Content-Type")}
+	obj.Size, _ = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	obj.ModTime, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	return resp.Body, obj, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.error("delete", key, resp)
+	}
+	return nil
+}
+
+func (s *S3Storage) error(op, key string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("storage: s3 %s %s: %s: %s", op, key, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// request builds a signed request. Keys are limited by ValidKey to
+// characters that need no escaping, so the path is used as is.
+func (s *S3Storage) request(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	if !ValidKey(key) {
+		return nil, fmt.Errorf("storage: invalid key %q", key)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.Endpoint+"/"+s.Bucket+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, body, time.Now().UTC())
+	return req, nil
+}
+
+func (s *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Get for a key that doesn't exist.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Object describes a stored blob.
+type Object struct {
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// Storage keeps uploaded files such as avatars. Keys are slash-separated
+// paths like "avatars/users/12/ab12cd-64.png"; see ValidKey.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var keyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*(/[a-z0-9][a-z0-9._-]*)*$`)
+
+// ValidKey rejects anything that could escape the storage root.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key) && !strings.Contains(key, "..")
+}
+
+// NewStorageFromEnv picks the backend from STORAGE_DRIVER: "local" (the
+// default, files under STORAGE_DIR or ./uploads) or "s3" for any
+// S3-compatible service configured through the S3_* variables.
+func NewStorageFromEnv() Storage {
+	switch os.Getenv("STORAGE_DRIVER") {
+	case "", "local":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		return NewLocalStorage(dir)
+	case "s3":
+		return NewS3Storage(
+			os.Getenv("S3_ENDPOINT"),
+			os.Getenv("S3_REGION"),
+			os.Getenv("S3_BUCKET"),
+			os.Getenv("S3_ACCESS_KEY_ID"),
+			os.Getenv("S3_SECRET_ACCESS_KEY"),
+		)
+	default:
+		log.Printf("unknown STORAGE_DRIVER %q, falling back to local", os.Getenv("STORAGE_DRIVER"))
+		return NewLocalStorage("uploads")
+	}
+}
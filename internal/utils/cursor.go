@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm/schema"
+)
+
+// cursor is the decoded form of ?cursor=. It holds the sort-key values of
+// the row the next page starts after (or, for Prev, ends before), plus the
+// sort it was made for so it can't be replayed against a different order.
+type cursor struct {
+	Sort   string            `json:"s"`
+	Prev   bool              `json:"p,omitempty"`
+	Values []json.RawMessage `json:"v"`
+	values []any
+}
+
+var schemaCache sync.Map
+
+// resolveKeys finds the model field behind every sort column. Keyset
+// paging needs them to read cursor values off rows; sorts on joined
+// columns fall back to offset paging.
+func (q *ListQuery) resolveKeys(model any) bool {
+	if model == nil {
+		return false
+	}
+	sch, err := schema.Parse(model, &schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return false
+	}
+
+	for i, k := range q.keys {
+		table, column, qualified := strings.Cut(k.column, ".")
+		if !qualified {
+			column, table = table, sch.Table
+		}
+		field := sch.LookUpField(column)
+		if table != sch.Table || field == nil {
+			return false
+		}
+		q.keys[i].field = field
+	}
+	return len(q.keys) > 0
+}
+
+func (q ListQuery) sortSignature() string {
+	parts := make([]string, len(q.keys))
+	for i, k := range q.keys {
+		parts[i] = k.column
+		if k.desc {
+			parts[i] = "-" + k.column
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (q ListQuery) decodeCursor(raw string) (*cursor, error) {
+	invalid := badListParam("Invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+	var cur cursor
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return nil, invalid
+	}
+	if cur.Sort != q.sortSignature() || len(cur.Values) != len(q.keys) {
+		return nil, badListParam("Cursor does not match the requested sort")
+	}
+
+	for i, k := range q.keys {
+		v := reflect.New(k.field.FieldType)
+		if err := json.Unmarshal(cur.Values[i], v.Interface()); err != nil {
+			return nil, invalid
+		}
+		cur.values = append(cur.values, v.Elem().Interface())
+	}
+	return &cur, nil
+}
+
+func (q ListQuery) encodeCursor(row any, prev bool) string {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	cur := cursor{Sort: q.sortSignature(), Prev: prev}
+
+	for _, k := range q.keys {
+		value, _ := k.field.ValueOf(context.Background(), v)
+		raw, err := json.Marshal(value)
+		if err != nil || string(raw) == "null" {
+			return ""
+		}
+		cur.Values = append(cur.Values, raw)
+	}
+
+	data, err := json.Marshal(cur)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// where builds the keyset condition: rows strictly after the cursor in
+// the sort order, or strictly before it for a prev cursor. Sorts can mix
+// directions, so it is spelled out rather than a row comparison:
+// (a > ?) OR (a = ? AND b > ?) OR ...
+func (cur *cursor) where(keys []sortKey) (string, []any) {
+	var ors []string
+	var args []any
+
+	for i, k := range keys {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, keys[j].column+" = ?")
+			args = append(args, cur.values[j])
+		}
+		op := ">"
+		if k.desc != cur.Prev {
+			op = "<"
+		}
+		ands = append(ands, k.column+" "+op+" ?")
+		args = append(args, cur.values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// PageInfo says what lies either side of a page.
+type PageInfo struct {
+	HasMore    bool
+	NextCursor string
+	PrevCursor string
+}
+
+// FinishPage trims rows fetched with ListQuery.Paginate to the page size,
+// puts them in display order and works out the cursors either side. It
+// needs the model rows, so call it before mapping them to DTOs.
+func FinishPage[T any](q ListQuery, rows []T) ([]T, PageInfo) {
+	extra := len(rows) > q.Limit
+	if extra {
+		rows = rows[:q.Limit]
+	}
+
+	moreAfter, moreBefore := extra, q.Offset > 0 || q.cursor != nil
+	if q.cursor != nil && q.cursor.Prev {
+		slices.Reverse(rows)
+		moreAfter, moreBefore = true, extra
+	}
+
+	info := PageInfo{HasMore: moreAfter}
+	if q.keyset && len(rows) > 0 {
+		if moreAfter {
+			info.NextCursor = q.encodeCursor(rows[len(rows)-1], false)
+		}
+		if moreBefore {
+			info.PrevCursor = q.encodeCursor(rows[0], true)
+		}
+	}
+	return rows, info
+}
+
+// SetLinkHeader adds RFC 8288 first/prev/next/last links for a page. Pages
+// fetched by cursor link by cursor; pages fetched by number link by number.
+func SetLinkHeader(c echo.Context, resp PaginatedResponse) {
+	var links []string
+	link := func(rel, param, value string) {
+		u := *c.Request().URL
+		u.Scheme, u.Host = c.Scheme(), c.Request().Host
+		query := u.Query()
+		query.Del("page")
+		query.Del("cursor")
+		if param != "" {
+			query.Set(param, value)
+		}
+		u.RawQuery = query.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel))
+	}
+
+	link("first", "", "")
+	if resp.Page == 0 {
+		if resp.PrevCursor != "" {
+			link("prev", "cursor", resp.PrevCursor)
+		}
+		if resp.NextCursor != "" {
+			link("next", "cursor", resp.NextCursor)
+		}
+	} else {
+		if resp.Page > 1 {
+			link("prev", "page", strconv.Itoa(resp.Page-1))
+		}
+		if resp.HasMore {
+			link("next", "page", strconv.Itoa(resp.Page+1))
+		}
+		if resp.TotalPages > 0 {
+			link("last", "page", strconv.Itoa(resp.TotalPages))
+		}
+	}
+
+	c.Response().Header().Set("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,278 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// FilterKind says how a filter[field]= value is parsed and matched.
+type FilterKind int
+
+const (
+	// FilterEquals matches exactly; comma-separated values match any of them.
+	FilterEquals FilterKind = iota
+	// FilterNumber is FilterEquals for integer columns.
+	FilterNumber
+	// FilterBool accepts true/false, 1/0.
+	FilterBool
+	// FilterContains is a case-insensitive substring match.
+	FilterContains
+)
+
+// Filter maps a filter[field]= name to a column.
+type Filter struct {
+	Column string
+	Kind   FilterKind
+}
+
+// ListSpec whitelists what a list endpoint can be sorted and filtered by.
+// Map keys are the names clients use in query params; values are SQL
+// columns, qualified when the endpoint joins other tables.
+//
+//	?sort=-view_count,name
+//	?filter[user_id]=3,4&filter[name]=bot
+//	?filter[created_at][from]=2024-01-01&filter[created_at][to]=2024-01-31
+type ListSpec struct {
+	Sorts map[string]string
+	// DefaultSort uses the same syntax as ?sort= and applies when it is absent.
+	DefaultSort string
+	Filters     map[string]Filter
+	// DateRanges accept [from] and [to] as RFC 3339 timestamps or dates; a
+	// date-only [to] includes that whole day.
+	DateRanges map[string]string
+	// Key is a unique column appended to every ordering so pages are stable.
+	Key string
+	// Model is the row type, e.g. &models.Agent{}. With it, sorts whose
+	// columns are all on the model can be paged by ?cursor= as well as
+	// ?page=.
+	Model any
+}
+
+// ListQuery is a parsed list request. Its methods are GORM scopes: Where
+// holds only the filters, so it is also what the total count should use.
+type ListQuery struct {
+	Pagination
+	keys   []sortKey
+	conds  []condition
+	keyset bool
+	cursor *cursor
+}
+
+type sortKey struct {
+	column string
+	desc   bool
+	field  *schema.Field
+}
+
+type condition struct {
+	sql  string
+	args []any
+}
+
+// ParseListQuery reads page, limit, sort and filter params against spec.
+// Anything not whitelisted is a 400 error.
+func ParseListQuery(c echo.Context, spec ListSpec) (ListQuery, error) {
+	q := ListQuery{Pagination: GetPagination(c)}
+
+	sort := c.QueryParam("sort")
+	if sort == "" {
+		sort = spec.DefaultSort
+	}
+	if err := q.parseSort(spec, sort); err != nil {
+		return q, err
+	}
+	q.keyset = q.resolveKeys(spec.Model)
+
+	if raw := c.QueryParam("cursor"); raw != "" {
+		if !q.keyset {
+			return q, badListParam("Cursor pagination is not supported for this sort")
+		}
+		cur, err := q.decodeCursor(raw)
+		if err != nil {
+			return q, err
+		}
+		q.cursor = cur
+		q.Page, q.Offset = 0, 0
+	}
+
+	for key, values := range c.QueryParams() {
+		if !strings.HasPrefix(key, "filter[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		path := strings.Split(strings.TrimSuffix(strings.TrimPrefix(key, "filter["), "]"), "][")
+		value := strings.TrimSpace(values[0])
+		if value == "" {
+			continue
+		}
+
+		var err error
+		switch len(path) {
+		case 1:
+			err = q.parseFilter(spec, path[0], value)
+		case 2:
+			err = q.parseDateRange(spec, path[0], path[1], value)
+		default:
+			err = badListParam("Invalid filter: " + key)
+		}
+		if err != nil {
+			return q, err
+		}
+	}
+
+	return q, nil
+}
+
+func (q *ListQuery) parseSort(spec ListSpec, sort string) error {
+	seen := map[string]bool{}
+	desc := false
+
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		desc = strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+		column, ok := spec.Sorts[field]
+		if !ok {
+			return badListParam("Unknown sort field: " + field)
+		}
+		if seen[column] {
+			continue
+		}
+		seen[column] = true
+		q.keys = append(q.keys, sortKey{column: column, desc: desc})
+	}
+
+	if spec.Key != "" && !seen[spec.Key] {
+		q.keys = append(q.keys, sortKey{column: spec.Key, desc: desc})
+	}
+	return nil
+}
+
+func (q *ListQuery) parseFilter(spec ListSpec, field, value string) error {
+	f, ok := spec.Filters[field]
+	if !ok {
+		return badListParam("Unknown filter: " + field)
+	}
+
+	switch f.Kind {
+	case FilterEquals:
+		q.where(f.Column+" IN ?", strings.Split(value, ","))
+	case FilterNumber:
+		var nums []int64
+		for _, v := range strings.Split(value, ",") {
+			n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return badListParam("Invalid number for filter " + field + ": " + v)
+			}
+			nums = append(nums, n)
+		}
+		q.where(f.Column+" IN ?", nums)
+	case FilterBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return badListParam("Invalid boolean for filter " + field + ": " + value)
+		}
+		q.where(f.Column+" = ?", b)
+	case FilterContains:
+		q.where(f.Column+" ILIKE ?", "%"+escapeLike(value)+"%")
+	}
+	return nil
+}
+
+func (q *ListQuery) parseDateRange(spec ListSpec, field, bound, value string) error {
+	column, ok := spec.DateRanges[field]
+	if !ok {
+		return badListParam("Unknown date filter: " + field)
+	}
+
+	t, dateOnly, err := parseTime(value)
+	if err != nil {
+		return badListParam("Invalid date for filter " + field + ": " + value)
+	}
+
+	switch bound {
+	case "from":
+		q.where(column+" >= ?", t)
+	case "to":
+		if dateOnly {
+			q.where(column+" < ?", t.AddDate(0, 0, 1))
+		} else {
+			q.where(column+" <= ?", t)
+		}
+	default:
+		return badListParam("Date filters take [from] or [to], not [" + bound + "]")
+	}
+	return nil
+}
+
+func (q *ListQuery) where(sql string, args ...any) {
+	q.conds = append(q.conds, condition{sql: sql, args: args})
+}
+
+// Where applies the filters.
+func (q ListQuery) Where(db *gorm.DB) *gorm.DB {
+	for _, c := range q.conds {
+		db = db.Where(c.sql, c.args...)
+	}
+	return db
+}
+
+// Order applies the sort.
+func (q ListQuery) Order(db *gorm.DB) *gorm.DB {
+	return q.orderBy(db, false)
+}
+
+func (q ListQuery) orderBy(db *gorm.DB, reverse bool) *gorm.DB {
+	for _, k := range q.keys {
+		dir := "ASC"
+		if k.desc != reverse {
+			dir = "DESC"
+		}
+		db = db.Order(k.column + " " + dir)
+	}
+	return db
+}
+
+// Paginate applies the sort and the page or cursor, fetching one row past
+// the page so FinishPage can tell whether there is more.
+func (q ListQuery) Paginate(db *gorm.DB) *gorm.DB {
+	if q.cursor == nil {
+		return q.Order(db).Limit(q.Limit + 1).Offset(q.Offset)
+	}
+	// A prev cursor walks backwards from the first row of the page the
+	// client is on; FinishPage flips the rows back into display order.
+	sql, args := q.cursor.where(q.keys)
+	return q.orderBy(db.Where(sql, args...), q.cursor.Prev).Limit(q.Limit + 1)
+}
+
+// Response wraps a page of results.
+func (q ListQuery) Response(data any, info PageInfo, total int64) PaginatedResponse {
+	resp := NewPaginatedResponse(data, q.Page, q.Limit, info.HasMore, total)
+	resp.NextCursor = info.NextCursor
+	resp.PrevCursor = info.PrevCursor
+	return resp
+}
+
+func parseTime(s string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	return t, true, err
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func badListParam(msg string) error {
+	return echo.NewHTTPError(http.StatusBadRequest, msg)
+}
@@ -6,6 +6,9 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// MaxLimit caps ?limit= so a single request can't pull a whole table.
+const MaxLimit = 100
+
 type Pagination struct {
 	Page   int
 	Limit  int
@@ -22,6 +25,9 @@ func GetPagination(c echo.Context) Pagination {
 	if limit < 1 {
 		limit = 10
 	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
 
 	offset := (page - 1) * limit
 
@@ -32,13 +38,17 @@ func GetPagination(c echo.Context) Pagination {
 	}
 }
 
+// PaginatedResponse is the envelope for every list endpoint. Page is 0 when
+// the page was fetched by cursor.
 type PaginatedResponse struct {
-	Data       any   `json:"data"`
-	Page       int   `json:"page"`
-	Limit      int   `json:"limit"`
-	HasMore    bool  `json:"hasMore"`
-	Total      int64 `json:"total"`
-	TotalPages int   `json:"totalPages"`
+	Data       any    `json:"data"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	HasMore    bool   `json:"hasMore"`
+	Total      int64  `json:"total"`
+	TotalPages int    `json:"totalPages"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
 }
 
 func NewPaginatedResponse(data any, page, limit int, hasMore bool, total int64) PaginatedResponse {
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"net/url"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -8,10 +11,33 @@ type CustomValidator struct {
 	validator *validator.Validate
 }
 
+// NewValidator also registers the "avatar" tag, see ValidAvatarURL.
 func NewValidator() *CustomValidator {
-	return &CustomValidator{validator: validator.New()}
+	v := validator.New()
+	v.RegisterValidation("avatar", func(fl validator.FieldLevel) bool {
+		return ValidAvatarURL(fl.Field().String())
+	})
+	return &CustomValidator{validator: v}
 }
 
 func (cv *CustomValidator) Validate(i interface{}) error {
 	return cv.validator.Struct(i)
 }
+
+// ValidAvatarURL accepts absolute http(s) URLs, and root-relative paths,
+// which is how this server's own media URLs look without PUBLIC_BASE_URL.
+// Other schemes, such as javascript: and data:, are refused wherever an
+// avatar is set.
+func ValidAvatarURL(s string) bool {
+	if len(s) > 2048 {
+		return false
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.User != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" {
+		return strings.HasPrefix(u.Path, "/")
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"os"
+	"strings"
 
 	"ai-agent-hub/internal/database"
+	"ai-agent-hub/internal/imaging"
 	"ai-agent-hub/internal/models"
 
 	"github.com/brianvoe/gofakeit/v6"
@@ -25,7 +28,17 @@ func main() {
 
 	database.Migrate(db)
 
+	// Avatars are identicons served by the API itself
+	publicBaseURL := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+
 	// Wipe existing data (for testing only)
+	db.Exec("DELETE FROM messages")
+	db.Exec("DELETE FROM conversations")
+	db.Exec("DELETE FROM agent_similarities")
+	db.Exec("DELETE FROM agent_trending_scores")
+	db.Exec("DELETE FROM agent_events")
+	db.Exec("DELETE FROM agent_daily_stats")
+	db.Exec("DELETE FROM agent_tags")
 	db.Exec("DELETE FROM agents")
 	db.Exec("DELETE FROM users")
 
@@ -34,13 +47,14 @@ func main() {
 			Username: fmt.Sprintf("user%d", i),
 			Email:    fmt.Sprintf("user%d@example.com", i),
 			Password: "hashed-password", // Replace with actual hash if needed
+			Avatar:   publicBaseURL + imaging.IdenticonPath(fmt.Sprintf("user-user%d", i)),
 		}
 
 		for j := 1; j <= 5; j++ {
 			agent := models.Agent{
 				Name:          fmt.Sprintf("Agent %d-%d", i, j),
 				Description:   gofakeit.HipsterSentence(10),
-				Avatar:        fmt.Sprintf("https://api.dicebear.com/7.x/identicon/svg?seed=%d%d", i, j),
+				Avatar:        publicBaseURL + imaging.IdenticonPath(fmt.Sprintf("agent-%d-%d", i, j)),
 				SystemPrompt:  gofakeit.Sentence(5),
 				InputTemplate: "{{input}}",
 				Personality:   []string{"friendly", "serious", "sarcastic", "formal"}[rand.Intn(4)],